@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/backup"
+	"github.com/plasmatrip/muslib/internal/config"
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// backupDB сохраняет резервную копию библиотеки.
+// Использование: backup [-o файл]
+func backupDB(ctx context.Context, args []string, log logger.Logger, db storage.Repository) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := flags.String("o", "muslib-"+time.Now().Format("20060102-150405")+".zip", "файл резервной копии")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return errors.New("usage: backup [-o file]")
+	}
+
+	// архив пишется во временный файл рядом с целевым, чтобы при ошибке не оставить неполную копию
+	tmp, err := os.CreateTemp(filepath.Dir(*out), ".muslib-backup-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	w := backup.NewWriter(tmp)
+	version, err := db.Dump(ctx, w)
+	if err != nil {
+		return err
+	}
+	manifest, err := w.Close(version)
+	if err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), *out); err != nil {
+		return err
+	}
+
+	var rows int64
+	for _, t := range manifest.Tables {
+		rows += t.Rows
+	}
+	log.Sugar.Infow("backup created", "file", *out, "schema_version", version, "tables", len(manifest.Tables), "rows", rows)
+
+	return nil
+}
+
+// restoreDB восстанавливает библиотеку из резервной копии: проверяет архив, приводит схему БД
+// к версии копии, загружает данные в одной транзакции и применяет оставшиеся миграции.
+// Схемой управляет сама команда, поэтому БД открывается без автоматической миграции.
+// Использование: restore [-force] <файл>
+func restoreDB(ctx context.Context, args []string, cfg config.Config, log logger.Logger) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	force := flags.Bool("force", false, "удалить данные, которые уже есть в БД")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: restore [-force] <file>")
+	}
+
+	db, err := storage.OpenRepository(ctx, cfg.Database, log)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	a, err := backup.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+
+	if err := a.Verify(); err != nil {
+		return err
+	}
+
+	migrations, err := storage.Migrations()
+	if err != nil {
+		return err
+	}
+	target := a.Manifest.SchemaVersion
+	latest := migrations[len(migrations)-1].Version
+	if target > latest {
+		return fmt.Errorf("backup schema version %d is newer than the latest known version %d, upgrade muslib", target, latest)
+	}
+
+	current, dirty, err := storage.SchemaVersion(cfg.Database)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", storage.ErrDirtySchema, current)
+	}
+
+	// с -force данные заменяются в транзакции загрузки. Откат схемы выполняется до нее и изменил бы
+	// существующие данные, даже если загрузка не удастся, поэтому он возможен только для пустой БД
+	if err := db.CheckEmpty(ctx); err != nil {
+		switch {
+		case !errors.Is(err, storage.ErrNotEmpty):
+			return err
+		case current > target:
+			return fmt.Errorf("%w and its schema version %d is newer than backup version %d: "+
+				"down migrations would change existing data before the restore, restore into an empty database", err, current, target)
+		case !*force:
+			return fmt.Errorf("%w, use -force to replace its contents", err)
+		}
+	}
+
+	if current != target {
+		log.Sugar.Infow("migrating schema to backup version", "from", current, "to", target)
+		if err := storage.MigrateTo(ctx, cfg.Database, target, migrateLogger{log}); err != nil {
+			return err
+		}
+	}
+
+	if err := db.Restore(ctx, a.TableNames(), *force, a.Rows); err != nil {
+		return err
+	}
+
+	if target != latest {
+		log.Sugar.Infow("migrating restored data to the latest schema", "from", target, "to", latest)
+		if err := storage.MigrateTo(ctx, cfg.Database, latest, migrateLogger{log}); err != nil {
+			return err
+		}
+	}
+
+	var rows int64
+	for _, t := range a.Manifest.Tables {
+		rows += t.Rows
+	}
+	log.Sugar.Infow("backup restored", "file", flags.Arg(0), "created_at", a.Manifest.CreatedAt,
+		"schema_version", target, "tables", len(a.Manifest.Tables), "rows", rows)
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plasmatrip/muslib/internal/config"
+	"github.com/plasmatrip/muslib/internal/infoservice/contract"
+	"github.com/plasmatrip/muslib/internal/lang"
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/rating"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// runStandaloneCommand выполняет служебную команду, которая не использует общее подключение к БД
+// с автоматической миграцией: управление схемой, восстановление из резервной копии и проверку
+// контракта внешнего сервиса. Возвращает false, если команде нужна БД
+func runStandaloneCommand(ctx context.Context, args []string, cfg config.Config, log logger.Logger) (bool, error) {
+	switch args[0] {
+	case "migrate":
+		return true, runMigrate(ctx, args[1:], cfg, log)
+	case "restore":
+		return true, restoreDB(ctx, args[1:], cfg, log)
+	case "check-contract":
+		return true, checkContract(ctx, args[1:], cfg, log)
+	default:
+		return false, nil
+	}
+}
+
+// runCommand выполняет служебную команду вместо запуска сервера
+func runCommand(ctx context.Context, args []string, cfg config.Config, log logger.Logger, db storage.Repository) error {
+	switch args[0] {
+	case "reenrich":
+		// ставим в очередь обновление данных всех песен, обработают их запущенные серверы
+		count, err := db.EnqueueAll(ctx, cfg.EnrichMaxAttempts)
+		if err != nil {
+			return err
+		}
+		log.Sugar.Infow("re-enrichment jobs queued", "count", count)
+		return nil
+	case "purge-cache":
+		// удаляем просроченные ответы внешнего сервиса из кэша в БД
+		count, err := db.DeleteExpiredCache(ctx)
+		if err != nil {
+			return err
+		}
+		log.Sugar.Infow("expired cache entries deleted", "count", count)
+		return nil
+	case "analyze":
+		// пересчитываем язык и ненормативную лексику песен, сохраненных до появления анализа
+		// текста или до изменения списков слов
+		rater, err := rating.NewRater(cfg.ExplicitWords)
+		if err != nil {
+			return err
+		}
+		count, err := db.AnalyzeSongs(ctx, func(song *model.Song) {
+			song.Lang = lang.Detect(song.Text)
+			rater.RateSong(song)
+		})
+		if err != nil {
+			return err
+		}
+		log.Sugar.Infow("song texts analyzed", "count", count)
+		return nil
+	case "scan":
+		return scanDir(ctx, args[1:], cfg, log, db)
+	case "import-musicbrainz":
+		return importMusicBrainz(ctx, args[1:], log, db)
+	case "backup":
+		return backupDB(ctx, args[1:], log, db)
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// contractCases песни для проверки контракта, если они не указаны в аргументах команды
+var contractCases = []contract.Case{
+	{Group: "Muse", Song: "Supermassive Black Hole"},
+	{Group: "Кино", Song: "Группа крови"},
+}
+
+// checkContract проверяет запросы клиента и ответы внешнего сервиса по контракту.
+// Аргументы задают пары "группа" "песня"
+func checkContract(ctx context.Context, args []string, cfg config.Config, log logger.Logger) error {
+	if len(args)%2 != 0 {
+		return fmt.Errorf("expected pairs of group and song, got %d arguments", len(args))
+	}
+
+	cases := contractCases
+	if len(args) > 0 {
+		cases = make([]contract.Case, 0, len(args)/2)
+		for i := 0; i < len(args); i += 2 {
+			cases = append(cases, contract.Case{Group: args[i], Song: args[i+1]})
+		}
+	}
+
+	c, err := contract.Load()
+	if err != nil {
+		return err
+	}
+
+	results, err := contract.Check(ctx, c, cfg.InfoService, cfg.ClientTimeout, cases)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.OK() {
+			log.Sugar.Infow("contract ok", "group", r.Group, "song", r.Song, "status", r.Status)
+			continue
+		}
+		failed++
+		for _, v := range r.Violations {
+			log.Sugar.Infow("contract violation", "group", r.Group, "song", r.Song, "status", r.Status, "violation", v)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks violate the info service contract", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/infoservice/contract"
+)
+
+// fixture ответ мок-сервиса для конкретной песни.
+// Поля хранятся как есть, чтобы фикстуры могли содержать и некорректные данные
+type fixture struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// checkFixtures сверяет фикстуры с контрактом внешнего сервиса и возвращает нарушения.
+// Фикстуры с нарушениями не отбрасываются: ими можно воспроизводить некорректные ответы
+func checkFixtures(fixtures map[string]fixture) map[string]error {
+	c, err := contract.Load()
+	if err != nil {
+		return map[string]error{"": err}
+	}
+
+	violations := make(map[string]error)
+	for _, f := range fixtures {
+		body, err := json.Marshal(f.response())
+		if err == nil {
+			err = c.ValidateResponse(http.MethodGet, "/info", http.StatusOK, "application/json", body)
+		}
+		if err != nil {
+			violations[f.Group+" - "+f.Song] = err
+		}
+	}
+	return violations
+}
+
+// response тело ответа GET /info
+func (f fixture) response() any {
+	return struct {
+		ReleaseDate string `json:"releaseDate"`
+		Text        string `json:"text"`
+		Link        string `json:"link"`
+	}{f.ReleaseDate, f.Text, f.Link}
+}
+
+// loadFixtures читает *.json из каталога. Файл содержит один объект или массив объектов
+func loadFixtures(dir string) (map[string]fixture, error) {
+	fixtures := make(map[string]fixture)
+	if dir == "" {
+		return fixtures, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		var list []fixture
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+			err = json.Unmarshal(data, &list)
+		} else {
+			var f fixture
+			err = json.Unmarshal(data, &f)
+			list = append(list, f)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+		}
+
+		for _, f := range list {
+			fixtures[infoservice.CacheKey(f.Group, f.Song)] = f
+		}
+	}
+
+	return fixtures, nil
+}
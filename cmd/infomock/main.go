@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/logger"
+)
+
+// options параметры мок-сервиса
+type options struct {
+	addr          string
+	fixtures      string
+	strict        bool
+	latency       time.Duration
+	latencyJitter time.Duration
+	errorRate     float64
+	malformedRate float64
+	notFoundRate  float64
+	seed          uint64
+	logLevel      string
+}
+
+func main() {
+	var opts options
+
+	flag.StringVar(&opts.addr, "addr", "localhost:8081", "адрес мок-сервиса")
+	flag.StringVar(&opts.fixtures, "fixtures", "", "каталог с JSON-фикстурами песен")
+	flag.BoolVar(&opts.strict, "strict", false, "отвечать 404 на песни, которых нет в фикстурах, вместо генерации")
+	flag.DurationVar(&opts.latency, "latency", 0, "задержка перед каждым ответом")
+	flag.DurationVar(&opts.latencyJitter, "latency-jitter", 0, "случайная добавка к задержке от 0 до указанного значения")
+	flag.Float64Var(&opts.errorRate, "error-rate", 0, "доля ответов с ошибками 5xx/429 (0..1)")
+	flag.Float64Var(&opts.malformedRate, "malformed-rate", 0, "доля ответов с некорректным телом (0..1)")
+	flag.Float64Var(&opts.notFoundRate, "not-found-rate", 0, "доля ответов 404 для сгенерированных песен (0..1)")
+	flag.Uint64Var(&opts.seed, "seed", 1, "зерно генератора для внедрения сбоев")
+	flag.StringVar(&opts.logLevel, "log-level", logger.LogLevelInfo, "уровень логирования")
+	flag.Parse()
+
+	// для грейсфул шатдауна слушаем сигнал ОС
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log, err := logger.NewLogger(opts.logLevel)
+	if err != nil {
+		panic(err)
+	}
+	defer log.Close()
+
+	fixtures, err := loadFixtures(opts.fixtures)
+	if err != nil {
+		log.Sugar.Infow("failed to load fixtures", "error", err)
+		os.Exit(1)
+	}
+
+	for key, err := range checkFixtures(fixtures) {
+		log.Sugar.Infow("fixture violates info service contract", "fixture", key, "error", err)
+	}
+
+	mock := newMock(opts, fixtures, *log)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", mock.info)
+
+	server := http.Server{
+		Addr:    opts.addr,
+		Handler: mux,
+	}
+
+	go server.ListenAndServe()
+
+	log.Sugar.Infow("The music info mock service is running", "address", opts.addr, "fixtures", len(fixtures))
+
+	// ждем сигнал ОС
+	<-ctx.Done()
+
+	server.Shutdown(context.Background())
+
+	log.Sugar.Infow("The mock service has been shut down gracefully")
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/logger"
+)
+
+// words словарь для генерации текстов песен
+var words = strings.Fields(`
+	night light heart fire rain road home dream love time city river
+	sky star shadow morning winter summer stone wind ocean song
+	falling burning running waiting calling dancing fading shining
+	never always again tonight forever away alone together
+	you me we I they my your our
+	hold take find leave carry follow remember forget
+`)
+
+// errorStatuses коды ответов при внедрении ошибок
+var errorStatuses = []int{
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusTooManyRequests,
+}
+
+// malformedBodies некорректные ответы при внедрении сбоев
+var malformedBodies = []string{
+	`{"releaseDate": "16.07.2006", "text": "truncated`,
+	`{"releaseDate": "July 16, 2006", "text": "wrong date format", "link": "https://example.com"}`,
+	`{"releaseDate": 2006, "text": ["not", "a", "string"]}`,
+	`{"releaseDate": "16-07-2006", "text": "bad link", "link": "not a url"}`,
+	`<html><body>502 Bad Gateway</body></html>`,
+}
+
+type mock struct {
+	opts     options
+	fixtures map[string]fixture
+	log      logger.Logger
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newMock(opts options, fixtures map[string]fixture, log logger.Logger) *mock {
+	return &mock{
+		opts:     opts,
+		fixtures: fixtures,
+		log:      log,
+		rnd:      rand.New(rand.NewPCG(opts.seed, opts.seed)),
+	}
+}
+
+// chance возвращает true с вероятностью rate
+func (m *mock) chance(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rnd.Float64() < rate
+}
+
+// pick возвращает случайный индекс от 0 до n
+func (m *mock) pick(n int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rnd.IntN(n)
+}
+
+// info отвечает на GET /info?group=&song=
+func (m *mock) info(w http.ResponseWriter, r *http.Request) {
+	group := r.URL.Query().Get("group")
+	song := r.URL.Query().Get("song")
+
+	m.delay(r)
+
+	if group == "" || song == "" {
+		http.Error(w, "group and song are required", http.StatusBadRequest)
+		return
+	}
+
+	if m.chance(m.opts.errorRate) {
+		status := errorStatuses[m.pick(len(errorStatuses))]
+		if status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable {
+			w.Header().Set("Retry-After", "1")
+		}
+		m.log.Sugar.Debugw("injected error", "group", group, "song", song, "status", status)
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	if m.chance(m.opts.malformedRate) {
+		body := malformedBodies[m.pick(len(malformedBodies))]
+		m.log.Sugar.Debugw("injected malformed payload", "group", group, "song", song, "body", body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+		return
+	}
+
+	f, ok := m.fixtures[infoservice.CacheKey(group, song)]
+	if !ok {
+		if m.opts.strict || m.chance(m.opts.notFoundRate) {
+			http.Error(w, "song not found", http.StatusNotFound)
+			return
+		}
+		f = generate(group, song)
+	}
+
+	m.log.Sugar.Debugw("song info served", "group", group, "song", song, "fixture", ok)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(f.response())
+}
+
+// delay выдерживает настроенную задержку, прерываясь при отмене запроса
+func (m *mock) delay(r *http.Request) {
+	d := m.opts.latency
+	if m.opts.latencyJitter > 0 {
+		d += time.Duration(m.pick(int(m.opts.latencyJitter)))
+	}
+	if d <= 0 {
+		return
+	}
+
+	select {
+	case <-r.Context().Done():
+	case <-time.After(d):
+	}
+}
+
+// generate детерминированно создает данные песни: одинаковые название группы
+// и песни всегда дают одинаковые дату, текст и ссылку
+func generate(group, song string) fixture {
+	h := fnv.New64a()
+	h.Write([]byte(infoservice.CacheKey(group, song)))
+	seed := h.Sum64()
+	rnd := rand.New(rand.NewPCG(seed, seed>>1))
+
+	release := time.Date(1960+rnd.IntN(65), time.Month(1+rnd.IntN(12)), 1+rnd.IntN(28), 0, 0, 0, 0, time.UTC)
+
+	verses := make([]string, 2+rnd.IntN(4))
+	for i := range verses {
+		lines := make([]string, 4)
+		for j := range lines {
+			line := make([]string, 4+rnd.IntN(4))
+			for k := range line {
+				line[k] = words[rnd.IntN(len(words))]
+			}
+			lines[j] = strings.ToUpper(line[0][:1]) + line[0][1:] + " " + strings.Join(line[1:], " ")
+		}
+		verses[i] = strings.Join(lines, "\n")
+	}
+
+	const alphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_"
+	id := make([]byte, 11)
+	for i := range id {
+		id[i] = alphabet[rnd.IntN(len(alphabet))]
+	}
+
+	return fixture{
+		Group:       group,
+		Song:        song,
+		ReleaseDate: release.Format("02-01-2006"),
+		Text:        strings.Join(verses, "\n\n"),
+		Link:        fmt.Sprintf("https://www.youtube.com/watch?v=%s", id),
+	}
+}
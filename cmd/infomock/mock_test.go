@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/infoservice/contract"
+	"github.com/plasmatrip/muslib/internal/logger"
+	"go.uber.org/zap"
+)
+
+func TestFixturesMatchContract(t *testing.T) {
+	fixtures, err := loadFixtures("fixtures")
+	if err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures loaded")
+	}
+
+	for key, err := range checkFixtures(fixtures) {
+		t.Errorf("fixture %s violates contract: %v", key, err)
+	}
+}
+
+func TestCheckFixturesReportsViolations(t *testing.T) {
+	fixtures := map[string]fixture{
+		"valid":     {Group: "a", Song: "valid", ReleaseDate: "16-07-2006", Text: "t", Link: "https://example.com"},
+		"iso date":  {Group: "a", Song: "iso date", ReleaseDate: "2006-07-16", Text: "t", Link: "https://example.com"},
+		"bad link":  {Group: "a", Song: "bad link", ReleaseDate: "16-07-2006", Text: "t", Link: "example"},
+		"no date":   {Group: "a", Song: "no date", Text: "t", Link: "https://example.com"},
+		"bad month": {Group: "a", Song: "bad month", ReleaseDate: "01-00-2006", Text: "t", Link: "https://example.com"},
+	}
+
+	violations := checkFixtures(fixtures)
+	for _, song := range []string{"iso date", "bad link", "no date", "bad month"} {
+		if violations["a - "+song] == nil {
+			t.Errorf("fixture %q: violation not reported", song)
+		}
+	}
+	if err := violations["a - valid"]; err != nil {
+		t.Errorf("valid fixture reported: %v", err)
+	}
+}
+
+// TestMockResponsesMatchContract проверяет ответы мок-сервиса (фикстуры и сгенерированные песни)
+// и запросы клиента muslib по контракту
+func TestMockResponsesMatchContract(t *testing.T) {
+	fixtures, err := loadFixtures("fixtures")
+	if err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+
+	m := newMock(options{seed: 1}, fixtures, logger.Logger{Sugar: zap.NewNop().Sugar()})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", m.info)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cases := []contract.Case{
+		{Group: "Кино", Song: "Группа крови"},
+		{Group: "Some Band", Song: "Generated & Song?"},
+	}
+	for _, f := range fixtures {
+		cases = append(cases, contract.Case{Group: f.Group, Song: f.Song})
+	}
+
+	c, err := contract.Load()
+	if err != nil {
+		t.Fatalf("failed to load contract: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	results, err := contract.Check(ctx, c, server.URL, time.Second, cases)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	for _, r := range results {
+		if r.Status != http.StatusOK {
+			t.Errorf("%s - %s: status %d, want 200", r.Group, r.Song, r.Status)
+		}
+		for _, v := range r.Violations {
+			t.Errorf("%s - %s: %v", r.Group, r.Song, v)
+		}
+		if r.ClientErr != nil {
+			t.Errorf("%s - %s: client error: %v", r.Group, r.Song, r.ClientErr)
+		}
+	}
+}
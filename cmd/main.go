@@ -6,8 +6,13 @@ import (
 	"os"
 	"os/signal"
 
+	"github.com/plasmatrip/muslib/internal/api/handlers"
 	"github.com/plasmatrip/muslib/internal/config"
+	"github.com/plasmatrip/muslib/internal/enrichment"
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/linkcheck"
 	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/rating"
 	"github.com/plasmatrip/muslib/internal/router"
 	"github.com/plasmatrip/muslib/internal/storage"
 )
@@ -30,21 +35,128 @@ func main() {
 	}
 	defer log.Close()
 
+	// служебные команды, которые не используют общее подключение к БД с автоматической миграцией
+	if len(os.Args) > 1 {
+		if ok, err := runStandaloneCommand(ctx, os.Args[1:], *cfg, *log); ok {
+			if err != nil {
+				log.Sugar.Infow("command failed", "command", os.Args[1], "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// инициализируем БД
-	db, err := storage.NewRepository(ctx, cfg.Database, *log)
+	db, err := storage.NewRepository(ctx, cfg.Database, cfg.AutoMigrate, *log)
 	if err != nil {
 		log.Sugar.Infow("database connection error: ", err)
 		os.Exit(1)
 	}
 	defer db.Close()
 
+	// выполняем служебную команду, если она указана
+	if len(os.Args) > 1 {
+		if err := runCommand(ctx, os.Args[1:], *cfg, *log, *db); err != nil {
+			log.Sugar.Infow("command failed", "command", os.Args[1], "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// загружаем списки ненормативной лексики
+	rater, err := rating.NewRater(cfg.ExplicitWords)
+	if err != nil {
+		log.Sugar.Infow("failed to load explicit word lists: ", err)
+		os.Exit(1)
+	}
+
+	// ограничитель частоты и количества одновременных запросов к внешнему сервису,
+	// действует на каждую попытку запроса, включая повторы
+	limiter := infoservice.NewLimitClient(infoservice.NewClient(cfg.InfoService, cfg.ClientTimeout), infoservice.LimitSettings{
+		RPS:         cfg.InfoRateLimit,
+		Burst:       cfg.InfoRateBurst,
+		MaxInFlight: cfg.InfoMaxInFlight,
+	})
+
+	// клиент внешнего сервиса
+	breaker := infoservice.NewBreakerClient(infoservice.NewRetryClient(limiter, infoservice.RetryPolicy{
+		MaxAttempts: cfg.RetryMaxAttempts,
+		BaseDelay:   cfg.RetryBaseDelay,
+		MaxDelay:    cfg.RetryMaxDelay,
+		Jitter:      cfg.RetryJitter,
+	}), infoservice.BreakerSettings{
+		FailureThreshold: cfg.BreakerFailureThreshold,
+		OpenTimeout:      cfg.BreakerOpenTimeout,
+		HalfOpenSuccess:  cfg.BreakerHalfOpenSuccess,
+	})
+
+	// кэш ответов внешнего сервиса, при необходимости с хранением в БД
+	var cacheStore infoservice.CacheStore
+	if cfg.InfoCachePersist {
+		cacheStore = *db
+	}
+	cache := infoservice.NewCacheClient(breaker, cacheStore, infoservice.CacheSettings{
+		Size:        cfg.InfoCacheSize,
+		TTL:         cfg.InfoCacheTTL,
+		NegativeTTL: cfg.InfoCacheNegativeTTL,
+	})
+
+	// цепочка источников данных о песнях
+	chain, err := newProviderChain(*cfg, cache)
+	if err != nil {
+		log.Sugar.Infow("failed to configure metadata providers: ", err)
+		os.Exit(1)
+	}
+
+	// запускаем обработчики очереди заданий на получение данных из внешнего сервиса
+	pool := enrichment.NewPool(*db, chain, breaker, rater, *log, enrichment.Settings{
+		Workers:       cfg.EnrichWorkers,
+		PollInterval:  cfg.EnrichInterval,
+		RetryDelay:    cfg.EnrichRetryDelay,
+		MaxRetryDelay: cfg.EnrichMaxRetryDelay,
+	})
+	poolDone := make(chan struct{})
+	go func() {
+		pool.Run(ctx)
+		close(poolDone)
+	}()
+
+	// запускаем периодическую проверку доступности ссылок песен
+	checkerDone := make(chan struct{})
+	if cfg.LinkCheckWorkers > 0 {
+		checker := linkcheck.NewChecker(*db, *log, linkcheck.Settings{
+			Workers:          cfg.LinkCheckWorkers,
+			PollInterval:     cfg.LinkCheckInterval,
+			MaxAge:           cfg.LinkCheckMaxAge,
+			HostDelay:        cfg.LinkCheckHostDelay,
+			Timeout:          cfg.LinkCheckTimeout,
+			MaxRedirects:     cfg.LinkCheckMaxRedirects,
+			FailureThreshold: cfg.LinkCheckFailures,
+		})
+		go func() {
+			checker.Run(ctx)
+			close(checkerDone)
+		}()
+	} else {
+		close(checkerDone)
+	}
+
 	// запускаем веб-сервер
 	server := http.Server{
 		Addr: cfg.Host,
 		Handler: func(next http.Handler) http.Handler {
 			log.Sugar.Infow("The Music Library server is running. ", "Server address", cfg.Host, "Music info service address", cfg.InfoService)
 			return next
-		}(router.NewRouter(*cfg, *log, *db)),
+		}(router.NewRouter(*log, &handlers.Handlers{
+			Config:      *cfg,
+			Logger:      *log,
+			Stor:        *db,
+			InfoService: chain,
+			Cache:       cache,
+			Limiter:     limiter,
+			Rater:       rater,
+			Refresher:   enrichment.NewRefresher(*db, chain, rater),
+		})),
 	}
 
 	go server.ListenAndServe()
@@ -54,6 +166,10 @@ func main() {
 
 	server.Shutdown(context.Background())
 
+	// ждем завершения обработчиков очереди и проверки ссылок
+	<-poolDone
+	<-checkerDone
+
 	log.Sugar.Infow("The server has been shut down gracefully")
 
 	os.Exit(0)
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/plasmatrip/muslib/internal/config"
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// migrateUsage справка по команде migrate
+const migrateUsage = "usage: migrate up [-dry-run] | down [-dry-run] <n> | goto [-dry-run] <version> | force <version> | status | conflicts"
+
+// parseMigrateArgs разбирает флаги подкоманды migrate args[0] и возвращает ее аргументы
+func parseMigrateArgs(args []string) ([]string, bool, error) {
+	// у force нет флагов, а версия -1 выглядит как флаг
+	if args[0] == "force" {
+		return args[1:], false, nil
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "только показать миграции, которые будут выполнены")
+	if err := flags.Parse(args[1:]); err != nil {
+		return nil, false, err
+	}
+
+	// флаги разбираются только до аргументов, поэтому флаг после аргумента был бы молча
+	// проигнорирован: `migrate down 1 -dry-run` выполнил бы миграцию
+	for _, arg := range flags.Args() {
+		if strings.HasPrefix(arg, "-") {
+			return nil, false, fmt.Errorf("flag %s must precede arguments, %s", arg, migrateUsage)
+		}
+	}
+
+	return flags.Args(), *dryRun, nil
+}
+
+// migrateLogger передает сообщения о применяемых миграциях в лог
+type migrateLogger struct {
+	log logger.Logger
+}
+
+func (l migrateLogger) Printf(format string, v ...interface{}) {
+	l.log.Sugar.Infof(format, v...)
+}
+
+// runMigrate управляет схемой БД. Выполняется без подключения к БД и автоматической миграции.
+// Использование: migrate up | down <n> | goto <версия> | force <версия> | status | conflicts
+func runMigrate(ctx context.Context, args []string, cfg config.Config, log logger.Logger) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	params, dryRun, err := parseMigrateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	migrations, err := storage.Migrations()
+	if err != nil {
+		return err
+	}
+
+	current, dirty, err := storage.SchemaVersion(cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		if len(params) != 0 {
+			return errors.New(migrateUsage)
+		}
+		return migrateStatus(migrations, current, dirty, log)
+	case "conflicts":
+		if len(params) != 0 {
+			return errors.New(migrateUsage)
+		}
+		return nameConflicts(ctx, cfg, log)
+	case "force":
+		if len(params) != 1 {
+			return errors.New(migrateUsage)
+		}
+		// -1 означает БД без примененных миграций
+		version, err := strconv.Atoi(params[0])
+		if err != nil || version < -1 {
+			return fmt.Errorf("invalid version %q", params[0])
+		}
+		if version > 0 && !hasMigration(migrations, uint(version)) {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+		if err := storage.ForceVersion(ctx, cfg.Database, version); err != nil {
+			return err
+		}
+		log.Sugar.Infow("schema version forced", "from", current, "dirty", dirty, "to", version)
+		return nil
+	}
+
+	if dirty {
+		return fmt.Errorf("%w at version %d, fix it and run `muslib migrate force`", storage.ErrDirtySchema, current)
+	}
+
+	// up и down выполняются как переход к целевой версии, чтобы dry-run показывал то же, что будет выполнено
+	var target uint
+	switch args[0] {
+	case "up":
+		if len(params) != 0 {
+			return errors.New(migrateUsage)
+		}
+		target = migrations[len(migrations)-1].Version
+	case "down":
+		if len(params) != 1 {
+			return errors.New(migrateUsage)
+		}
+		n, err := strconv.Atoi(params[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations %q", params[0])
+		}
+		applied := appliedMigrations(migrations, current)
+		if n > len(applied) {
+			return fmt.Errorf("cannot roll back %d migrations, only %d applied", n, len(applied))
+		}
+		if n < len(applied) {
+			target = applied[len(applied)-n-1].Version
+		}
+	case "goto":
+		if len(params) != 1 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseUint(params[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", params[0])
+		}
+		target = uint(version)
+		if target > 0 && !hasMigration(migrations, target) {
+			return fmt.Errorf("unknown migration version %d", target)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+
+	up, down := plannedMigrations(migrations, current, target)
+	if len(up) == 0 && len(down) == 0 {
+		log.Sugar.Infow("schema is already at the target version", "version", current)
+		return nil
+	}
+
+	if dryRun {
+		for _, m := range up {
+			log.Sugar.Infow("would apply migration", "version", m.Version, "name", m.Name)
+		}
+		for _, m := range down {
+			log.Sugar.Infow("would roll back migration", "version", m.Version, "name", m.Name)
+		}
+		// конфликты названий возможны только до ограничения уникальности, то есть при применении миграций
+		if len(up) > 0 {
+			conflicts, err := storage.NameConflicts(ctx, cfg.Database)
+			if err != nil {
+				return err
+			}
+			if len(conflicts) > 0 {
+				return fmt.Errorf("%w: %d groups, run `muslib migrate conflicts` to list them", storage.ErrNameConflicts, len(conflicts))
+			}
+		}
+		return nil
+	}
+
+	ml := migrateLogger{log}
+	switch {
+	case target == migrations[len(migrations)-1].Version:
+		err = storage.MigrateUp(ctx, cfg.Database, ml)
+	case target == 0:
+		// версии 0 нет среди миграций, поэтому откатываем все примененные
+		err = storage.MigrateDown(ctx, cfg.Database, len(down), ml)
+	default:
+		err = storage.MigrateTo(ctx, cfg.Database, target, ml)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Sugar.Infow("schema migrated", "from", current, "to", target, "applied", len(up), "rolled_back", len(down))
+	return nil
+}
+
+// migrateStatus выводит версию схемы и состояние каждой миграции
+func migrateStatus(migrations []storage.Migration, current uint, dirty bool, log logger.Logger) error {
+	latest := migrations[len(migrations)-1].Version
+	pending := len(migrations) - len(appliedMigrations(migrations, current))
+
+	log.Sugar.Infow("schema status", "version", current, "dirty", dirty, "latest", latest, "pending", pending)
+	for _, m := range migrations {
+		state := "pending"
+		switch {
+		case m.Version == current && dirty:
+			state = "dirty"
+		case m.Version <= current:
+			state = "applied"
+		}
+		log.Sugar.Infow("migration", "version", m.Version, "name", m.Name, "state", state)
+	}
+	return nil
+}
+
+// nameConflicts выводит песни, из-за которых нельзя применить ограничение уникальности названий
+func nameConflicts(ctx context.Context, cfg config.Config, log logger.Logger) error {
+	conflicts, err := storage.NameConflicts(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range conflicts {
+		ids := make([]int, 0, len(c.Songs))
+		names := make([]string, 0, len(c.Songs))
+		for _, s := range c.Songs {
+			ids = append(ids, s.ID)
+			names = append(names, fmt.Sprintf("%q - %q", s.Group, s.Song))
+		}
+		log.Sugar.Infow("conflicting song names", "group", c.Group, "song", c.Song, "ids", ids, "names", names)
+	}
+	log.Sugar.Infow("name conflicts checked", "conflicts", len(conflicts))
+	return nil
+}
+
+// plannedMigrations возвращает миграции, которые будут применены или откачены
+// при переходе от версии current к версии target, в порядке выполнения
+func plannedMigrations(migrations []storage.Migration, current, target uint) (up, down []storage.Migration) {
+	for _, m := range migrations {
+		switch {
+		case m.Version > current && m.Version <= target:
+			up = append(up, m)
+		case m.Version <= current && m.Version > target:
+			down = append(down, m)
+		}
+	}
+	slices.Reverse(down)
+	return up, down
+}
+
+// appliedMigrations возвращает миграции с версией не выше current
+func appliedMigrations(migrations []storage.Migration, current uint) []storage.Migration {
+	var applied []storage.Migration
+	for _, m := range migrations {
+		if m.Version <= current {
+			applied = append(applied, m)
+		}
+	}
+	return applied
+}
+
+// hasMigration проверяет, что среди встроенных миграций есть версия version
+func hasMigration(migrations []storage.Migration, version uint) bool {
+	return slices.ContainsFunc(migrations, func(m storage.Migration) bool { return m.Version == version })
+}
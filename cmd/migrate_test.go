@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseMigrateArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		params  []string
+		dryRun  bool
+		wantErr string
+	}{
+		{name: "up", args: []string{"up"}},
+		{name: "dry run before argument", args: []string{"down", "-dry-run", "1"}, params: []string{"1"}, dryRun: true},
+		{name: "dry run after argument", args: []string{"down", "1", "-dry-run"}, wantErr: "flag -dry-run must precede arguments"},
+		{name: "dry run after version", args: []string{"goto", "3", "--dry-run"}, wantErr: "flag --dry-run must precede arguments"},
+		{name: "unknown flag", args: []string{"up", "-force"}, wantErr: "flag provided but not defined"},
+		{name: "force to no migrations", args: []string{"force", "-1"}, params: []string{"-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, dryRun, err := parseMigrateArgs(tt.args)
+			switch {
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "":
+				return
+			}
+			if !slices.Equal(params, tt.params) && len(params)+len(tt.params) > 0 {
+				t.Errorf("params = %q, want %q", params, tt.params)
+			}
+			if dryRun != tt.dryRun {
+				t.Errorf("dry run = %v, want %v", dryRun, tt.dryRun)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/musicbrainz"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// mbBatchSize количество песен, сохраняемых в одной транзакции вместе с позицией импорта
+const mbBatchSize = 500
+
+// importMusicBrainz импортирует песни из JSON-дампов MusicBrainz.
+// Использование: import-musicbrainz [-restart] <файл или каталог>...
+func importMusicBrainz(ctx context.Context, args []string, log logger.Logger, db storage.Repository) error {
+	flags := flag.NewFlagSet("import-musicbrainz", flag.ContinueOnError)
+	restart := flags.Bool("restart", false, "начать импорт файлов заново, не учитывая сохраненную позицию")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return errors.New("usage: import-musicbrainz [-restart] <file or dir>...")
+	}
+
+	var files []string
+	for _, arg := range flags.Args() {
+		found, err := dumpFiles(arg)
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+	if len(files) == 0 {
+		return errors.New("no artist, release or recording dump files found")
+	}
+
+	for _, path := range files {
+		if err := importDump(ctx, path, *restart, log, db); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dumpFiles возвращает файлы дампов: сам файл или файлы сущностей в каталоге.
+// Релизы импортируются раньше записей
+func dumpFiles(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		if musicbrainz.Entity(path) == "" {
+			return nil, errors.New("unknown dump file " + path + ", artist, release or recording expected")
+		}
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && musicbrainz.Entity(p) != "" {
+			files = append(files, p)
+		}
+		return nil
+	})
+
+	order := map[string]int{musicbrainz.EntityArtist: 0, musicbrainz.EntityRelease: 1, musicbrainz.EntityRecording: 2}
+	sort.SliceStable(files, func(i, j int) bool {
+		return order[musicbrainz.Entity(files[i])] < order[musicbrainz.Entity(files[j])]
+	})
+
+	return files, err
+}
+
+// importDump импортирует один файл дампа, продолжая с сохраненной позиции
+func importDump(ctx context.Context, path string, restart bool, log logger.Logger, db storage.Repository) error {
+	entity := musicbrainz.Entity(path)
+	if entity == musicbrainz.EntityArtist {
+		// в библиотеке нет отдельной таблицы исполнителей, а имена исполнителей
+		// содержатся в релизах и записях
+		log.Sugar.Infow("artist dump skipped: artists are taken from release and recording credits", "file", path)
+		return nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	rd, err := musicbrainz.Open(path)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+
+	fingerprint, err := rd.Fingerprint()
+	if err != nil {
+		return err
+	}
+
+	progress, err := db.GetImportProgress(ctx, musicbrainz.Source+":"+abs)
+	if err != nil {
+		return err
+	}
+	start := progress.Position
+	if restart || progress.Fingerprint != fingerprint {
+		start = 0
+	}
+	progress.Fingerprint = fingerprint
+	if start > 0 {
+		log.Sugar.Infow("resuming musicbrainz import", "file", path, "line", start)
+	}
+
+	var (
+		songs          []model.Song
+		entities, skip int64
+		saved          int
+	)
+
+	flush := func(line int64) error {
+		progress.Position = line
+		if err := db.ImportMusicBrainz(ctx, songs, musicbrainz.Source, progress); err != nil {
+			return err
+		}
+		saved += len(songs)
+		songs = songs[:0]
+		return nil
+	}
+
+	var line int64
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var data []byte
+		line, data, err = rd.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if line <= start {
+			continue
+		}
+
+		entities++
+		found, err := musicbrainz.Songs(entity, data)
+		if err != nil {
+			log.Sugar.Infow("invalid dump line", "file", path, "line", line, "error", err)
+			skip++
+			continue
+		}
+		songs = append(songs, found...)
+
+		if len(songs) >= mbBatchSize {
+			if err := flush(line); err != nil {
+				return err
+			}
+		}
+	}
+
+	// позиция сохраняется и при пустом остатке, чтобы повторный запуск не читал файл заново
+	if line > start {
+		if err := flush(line); err != nil {
+			return err
+		}
+	}
+
+	log.Sugar.Infow("musicbrainz import finished", "file", path, "entities", entities, "songs", saved, "invalid", skip)
+
+	return nil
+}
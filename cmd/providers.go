@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/plasmatrip/muslib/internal/config"
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/providers"
+)
+
+// newProviderChain собирает цепочку источников данных о песнях в порядке, заданном в конфигурации
+func newProviderChain(cfg config.Config, info infoservice.Client) (*providers.Chain, error) {
+	var list []providers.Provider
+
+	for _, name := range cfg.Providers {
+		name = strings.TrimSpace(name)
+
+		switch name {
+		case "info":
+			list = append(list, providers.Provider{Name: name, Client: info})
+		case "file":
+			if cfg.ProviderFile == "" {
+				return nil, errors.New("PROVIDER_FILE not set")
+			}
+			p, err := providers.NewFileProvider(cfg.ProviderFile)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, providers.Provider{Name: name, Client: p})
+		case "http":
+			if cfg.ProviderHTTPURL == "" {
+				return nil, errors.New("PROVIDER_HTTP_URL not set")
+			}
+			mapping, err := providers.ParseMapping(cfg.ProviderHTTPMapping)
+			if err != nil {
+				return nil, fmt.Errorf("invalid PROVIDER_HTTP_MAPPING: %w", err)
+			}
+			p := providers.NewMappedHTTPProvider(cfg.ProviderHTTPURL, mapping, cfg.ProviderHTTPDateLayout, cfg.ClientTimeout)
+			list = append(list, providers.Provider{Name: name, Client: infoservice.NewRetryClient(p, infoservice.RetryPolicy{
+				MaxAttempts: cfg.RetryMaxAttempts,
+				BaseDelay:   cfg.RetryBaseDelay,
+				MaxDelay:    cfg.RetryMaxDelay,
+				Jitter:      cfg.RetryJitter,
+			})})
+		default:
+			return nil, fmt.Errorf("unknown provider %q", name)
+		}
+	}
+
+	if len(list) == 0 {
+		return nil, errors.New("no metadata providers configured")
+	}
+
+	return providers.NewChain(list...), nil
+}
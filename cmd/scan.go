@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/plasmatrip/muslib/internal/config"
+	"github.com/plasmatrip/muslib/internal/importer"
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/rating"
+	"github.com/plasmatrip/muslib/internal/storage"
+	"github.com/plasmatrip/muslib/internal/tags"
+)
+
+// scanBatchSize количество песен в одном пакете запросов к БД
+const scanBatchSize = 500
+
+// scanDir обходит каталог, читает метаданные аудиофайлов и сохраняет песни в библиотеку.
+// Использование: scan [-mode upsert|skip|insert] [-enrich] <dir>
+func scanDir(ctx context.Context, args []string, cfg config.Config, log logger.Logger, db storage.Repository) error {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	mode := flags.String("mode", model.ImportUpsert, "что делать с песнями, которые уже есть в библиотеке: upsert, skip или insert")
+	enrich := flags.Bool("enrich", false, "ставить задания на получение данных из внешнего сервиса для добавленных песен")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: scan [-mode upsert|skip|insert] [-enrich] <dir>")
+	}
+	switch *mode {
+	case model.ImportInsert, model.ImportUpsert, model.ImportSkip:
+	default:
+		return errors.New("invalid mode, upsert, skip or insert expected")
+	}
+
+	rater, err := rating.NewRater(cfg.ExplicitWords)
+	if err != nil {
+		return err
+	}
+
+	var (
+		report model.ImportReport
+		songs  []model.Song
+		files  int
+	)
+
+	flush := func() error {
+		if len(songs) == 0 {
+			return nil
+		}
+		results, err := db.ImportSongs(ctx, songs, *mode, *enrich, cfg.EnrichMaxAttempts)
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			report.Add(r)
+			if r.Status == model.ImportFailed {
+				log.Sugar.Infow("song not saved", "group", r.Group, "song", r.Song, "error", r.Error)
+			}
+		}
+		songs = songs[:0]
+		return nil
+	}
+
+	err = filepath.WalkDir(flags.Arg(0), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			log.Sugar.Infow("failed to read directory", "path", path, "error", err)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !tags.Supported(path) {
+			return nil
+		}
+		files++
+
+		t, err := tags.Read(path)
+		if err != nil {
+			log.Sugar.Infow("failed to read tags", "file", path, "error", err)
+			report.Add(model.ImportRowResult{Status: model.ImportFailed, Error: err.Error()})
+			return nil
+		}
+		if t.Artist == "" || t.Title == "" {
+			log.Sugar.Debugw("file skipped: no artist or title", "file", path)
+			report.Add(model.ImportRowResult{Status: model.ImportSkipped})
+			return nil
+		}
+
+		rec := importer.Record{Group: t.Artist, Song: t.Title, ReleaseDate: t.Date, Text: t.Lyrics}
+		song, err := rec.ToSong(rater)
+		if err != nil && rec.ReleaseDate != "" {
+			// дата в тегах часто записана в произвольном виде: сохраняем из нее только год
+			log.Sugar.Debugw("release date reduced to year", "file", path, "date", rec.ReleaseDate, "error", err)
+			rec.ReleaseDate = leadingYear(rec.ReleaseDate)
+			song, err = rec.ToSong(rater)
+		}
+		if err != nil && rec.ReleaseDate != "" {
+			// год тоже не подошел, песню сохраняем без даты
+			log.Sugar.Debugw("release date ignored", "file", path, "date", rec.ReleaseDate, "error", err)
+			rec.ReleaseDate = ""
+			song, err = rec.ToSong(rater)
+		}
+		if err != nil {
+			log.Sugar.Infow("invalid tags", "file", path, "error", err)
+			report.Add(model.ImportRowResult{Group: song.Group, Song: song.Song, Status: model.ImportFailed, Error: err.Error()})
+			return nil
+		}
+
+		songs = append(songs, song)
+		if len(songs) >= scanBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Sugar.Infow("scan finished", "files", files, "inserted", report.Inserted, "updated", report.Updated,
+		"skipped", report.Skipped, "failed", report.Failed)
+
+	return nil
+}
+
+// leadingYear возвращает год из начала даты в произвольном виде ("2009/09/14", "2009-09-14 12:00")
+// или пустую строку, если дата не начинается с четырех цифр
+func leadingYear(date string) string {
+	date = strings.TrimSpace(date)
+	if len(date) < 4 {
+		return ""
+	}
+	for i := 0; i < 4; i++ {
+		if date[i] < '0' || date[i] > '9' {
+			return ""
+		}
+	}
+	if len(date) > 4 && date[4] >= '0' && date[4] <= '9' {
+		return ""
+	}
+	return date[:4]
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestLeadingYear(t *testing.T) {
+	tests := []struct {
+		date string
+		want string
+	}{
+		{date: "2009", want: "2009"},
+		{date: "2009/09/14", want: "2009"},
+		{date: "2009-09-14T00:00:00", want: "2009"},
+		{date: "2009-09-14 12:00", want: "2009"},
+		{date: " 2009.09 ", want: "2009"},
+		{date: "20090914", want: ""},
+		{date: "09/14/2009", want: ""},
+		{date: "'09", want: ""},
+		{date: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			if got := leadingYear(tt.date); got != tt.want {
+				t.Errorf("leadingYear(%q) = %q, want %q", tt.date, got, tt.want)
+			}
+		})
+	}
+}
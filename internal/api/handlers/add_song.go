@@ -3,14 +3,15 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
-	"net/url"
 
+	"github.com/plasmatrip/muslib/internal/lang"
+	"github.com/plasmatrip/muslib/internal/links"
 	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage"
 )
 
-// AddSong добавляет новую песню
+// AddSong добавляет новую песню, данные внешнего сервиса получаются в фоне
 func (h *Handlers) AddSong(w http.ResponseWriter, r *http.Request) {
 	var song model.Song
 
@@ -21,6 +22,9 @@ func (h *Handlers) AddSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Приводим названия к каноническому виду
+	song.Normalize()
+
 	// Проверяем параметры
 	if len(song.Song) == 0 || len(song.Group) == 0 {
 		h.Logger.Sugar.Infow("error adding song", "error", errors.New("empty group name or song name"))
@@ -28,50 +32,59 @@ func (h *Handlers) AddSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	params := url.Values{}
-	params.Add("group", song.Group)
-	params.Add("song", song.Song)
-
-	fullURL := fmt.Sprintf("%s/info?%s", h.Config.InfoService, params.Encode())
-
-	// Отправляем запрос к внешнему сервису для получения раширенной информации о песне
-	req, err := http.NewRequest(http.MethodGet, fullURL, nil)
-	if err != nil {
-		h.Logger.Sugar.Infow("failed to create request", "error", err)
-		http.Error(w, "error processing request", http.StatusInternalServerError)
-		return
+	// Проверяем ссылку
+	if song.Link != "" {
+		if _, err := links.Parse(song.Link, ""); err != nil {
+			h.Logger.Sugar.Infow("invalid link", "link", song.Link, "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 	}
 
-	resp, err := h.Client.Do(req)
-	if err != nil {
-		h.Logger.Sugar.Infow("failed to send request", "error", err)
-		http.Error(w, "error processing request", http.StatusInternalServerError)
-		return
+	// Проверяем и приводим к каноническому виду дополнительные ссылки
+	for i, link := range song.Links {
+		parsed, err := links.Parse(link.URL, link.Kind)
+		if err != nil {
+			h.Logger.Sugar.Infow("error adding song", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		song.Links[i] = parsed
 	}
-	defer resp.Body.Close()
 
-	// Проверяем код ответа
-	if resp.StatusCode != http.StatusOK {
-		h.Logger.Sugar.Infow("received non-200 response", "status: ", resp.StatusCode)
-		http.Error(w, "error processing request", http.StatusInternalServerError)
-		return
+	// Проверяем и приводим к каноническому виду внешние идентификаторы
+	for i, id := range song.ExternalIDs {
+		parsed, err := model.ParseExternalID(id.Scheme, id.Value)
+		if err != nil {
+			h.Logger.Sugar.Infow("error adding song", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		song.ExternalIDs[i] = parsed
 	}
 
-	// Декодируем ответ
-	if err := json.NewDecoder(resp.Body).Decode(&song); err != nil {
-		h.Logger.Sugar.Infow("failed to decode response", "error", err)
-		http.Error(w, "error processing request", http.StatusInternalServerError)
-		return
-	}
+	// Определяем язык и проверяем на ненормативную лексику переданный текст,
+	// чтобы песня попадала в фильтры, даже если данные из внешнего сервиса не будут получены
+	song.Lang = lang.Detect(song.Text)
+	h.Rater.RateSong(&song)
 
-	// Добавляем песню в базу
-	if err := h.Stor.AddSong(r.Context(), song); err != nil {
+	// Добавляем песню в базу и ставим в очередь задание на получение
+	// расширенной информации из внешнего сервиса
+	if err := h.Stor.AddSongAndEnqueue(r.Context(), song, h.Config.EnrichMaxAttempts); err != nil {
 		h.Logger.Sugar.Infow("failed to add song", "error", err)
+		if errors.Is(err, storage.ErrSongExists) {
+			http.Error(w, "song already exists", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, storage.ErrExternalIDExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "error processing request", http.StatusInternalServerError)
 		return
 	}
 
-	h.Logger.Sugar.Infow("song added successfully", "group", song.Group, "song", song.Song)
+	h.Logger.Sugar.Infow("song added successfully, enrichment queued", "group", song.Group, "song", song.Song)
 
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusAccepted)
 }
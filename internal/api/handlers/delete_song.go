@@ -19,6 +19,9 @@ func (h *Handlers) DeleteSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Приводим названия к каноническому виду
+	song.Normalize()
+
 	// Проверяем параметры
 	if len(song.Song) == 0 || len(song.Group) == 0 {
 		h.Logger.Sugar.Infow("error deleting song", "error", errors.New("empty group name or song name"))
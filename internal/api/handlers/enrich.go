@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// EnrichSong повторно получает данные песни из внешнего сервиса и возвращает изменения.
+// С параметром dry_run=true изменения не сохраняются
+func (h *Handlers) EnrichSong(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.Logger.Sugar.Infow("invalid song id", "id", chi.URLParam(r, "id"))
+		http.Error(w, "invalid song id", http.StatusBadRequest)
+		return
+	}
+
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	song, err := h.Stor.GetSong(r.Context(), id)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to fetch song", "id", id, "error", err)
+		http.Error(w, err.Error(), enrichStatus(err))
+		return
+	}
+
+	result, err := h.Refresher.Refresh(r.Context(), song, !dryRun)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to re-enrich song", "id", id, "group", song.Group, "song", song.Song, "error", err)
+		http.Error(w, err.Error(), enrichStatus(err))
+		return
+	}
+
+	h.Logger.Sugar.Infow("song re-enriched", "id", id, "changes", len(result.Changes), "applied", result.Applied)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// EnrichSongs повторно получает данные всех песен, отобранных фильтром GET /songs
+// (лимит и страница не учитываются). Данные обновляют фоновые задания в обход кэша,
+// в ответе - количество поставленных заданий. С dry_run=true изменения по каждой песне
+// запрашиваются сразу и передаются потоком, ошибки по отдельным песням не прерывают обработку
+func (h *Handlers) EnrichSongs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseQueryParams(r)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to parse query params", "error", err)
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	dryRun, err := parseDryRun(r)
+	if err != nil {
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	if dryRun {
+		h.previewEnrichSongs(w, r, filter)
+		return
+	}
+
+	count, err := h.Stor.EnqueueSongs(r.Context(), filter, h.Config.EnrichMaxAttempts)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to queue re-enrichment", "error", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Sugar.Infow("re-enrichment jobs queued", "count", count)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]int64{"queued": count})
+}
+
+// previewEnrichSongs запрашивает данные песен без сохранения и передает изменения
+// JSON-массивом по мере получения. Сначала выбираются идентификаторы песен, затем каждая
+// песня читается отдельно: запросы к внешнему сервису не держат открытой транзакцию
+func (h *Handlers) previewEnrichSongs(w http.ResponseWriter, r *http.Request, filter *model.Filter) {
+	ids, err := h.Stor.GetSongIDs(r.Context(), filter)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to select songs for re-enrichment preview", "error", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(ids) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("["))
+
+	count := 0
+	for _, id := range ids {
+		if err := r.Context().Err(); err != nil {
+			// клиент отключился, ответ просто обрывается
+			h.Logger.Sugar.Infow("re-enrichment preview interrupted", "processed", count, "error", err)
+			return
+		}
+
+		result := model.EnrichResult{ID: id}
+		song, err := h.Stor.GetSong(r.Context(), id)
+		if errors.Is(err, storage.ErrSongNotFound) {
+			// песню удалили после выбора идентификаторов
+			continue
+		}
+		if err == nil {
+			result, err = h.Refresher.Refresh(r.Context(), song, false)
+		}
+		if err != nil {
+			h.Logger.Sugar.Debugw("failed to re-enrich song", "id", id, "error", err)
+			result.Error = err.Error()
+		}
+
+		if count > 0 {
+			w.Write([]byte(","))
+		}
+		count++
+		if err := enc.Encode(result); err != nil {
+			h.Logger.Sugar.Infow("failed to write re-enrichment preview", "processed", count, "error", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	w.Write([]byte("]"))
+
+	h.Logger.Sugar.Infow("re-enrichment previewed", "count", count)
+}
+
+// parseDryRun разбирает параметр dry_run
+func parseDryRun(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("dry_run")
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+// enrichStatus возвращает код ответа для ошибки повторного получения данных
+func enrichStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrSongNotFound), errors.Is(err, infoservice.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, infoservice.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, infoservice.ErrUnavailable), errors.Is(err, infoservice.ErrBadPayload):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// SetExplicit вручную устанавливает или снимает признак ненормативной лексики
+func (h *Handlers) SetExplicit(w http.ResponseWriter, r *http.Request) {
+	var override model.ExplicitOverride
+
+	// Разбираем тело запроса
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Приводим названия к каноническому виду
+	override.Normalize()
+
+	// Проверяем параметры
+	if len(override.Song) == 0 || len(override.Group) == 0 {
+		h.Logger.Sugar.Infow("error setting explicit flag", "error", errors.New("empty group name or song name"))
+		http.Error(w, "empty group name or song name", http.StatusBadRequest)
+		return
+	}
+
+	// Сохраняем признак
+	if err := h.Stor.SetExplicitOverride(r.Context(), override); err != nil {
+		h.Logger.Sugar.Infow("failed to set explicit flag", "error", err)
+		if errors.Is(err, storage.ErrSongNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error processing request", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Sugar.Infow("explicit flag set successfully", "group", override.Group, "song", override.Song, "explicit", override.Explicit)
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// exportColumns колонки CSV при выгрузке библиотеки
+var exportColumns = []string{"id", "group", "song", "releaseDate", "text", "link", "lang", "explicit", "enriched_at"}
+
+// ExportSongs выгружает всю библиотеку или песни, подходящие под фильтры GET /songs,
+// в формате format: ndjson (по умолчанию), csv или json. Ответ передается потоком
+func (h *Handlers) ExportSongs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseQueryParams(r)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to parse query params", "error", err)
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ndjson"
+	}
+
+	var (
+		write  func(model.Song) error
+		finish func() error
+	)
+
+	switch format {
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		write = func(s model.Song) error { return enc.Encode(s) }
+		finish = func() error { return nil }
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		first := true
+		write = func(s model.Song) error {
+			sep := ","
+			if first {
+				sep, first = "[", false
+			}
+			data, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(append([]byte(sep), data...))
+			return err
+		}
+		finish = func() error {
+			end := "]"
+			if first {
+				end = "[]"
+			}
+			_, err := w.Write([]byte(end + "\n"))
+			return err
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		cw := csv.NewWriter(w)
+		header := false
+		write = func(s model.Song) error {
+			if !header {
+				header = true
+				if err := cw.Write(exportColumns); err != nil {
+					return err
+				}
+			}
+			return cw.Write(songRecord(s))
+		}
+		finish = func() error {
+			if !header {
+				cw.Write(exportColumns)
+			}
+			cw.Flush()
+			return cw.Error()
+		}
+	default:
+		http.Error(w, "invalid export format", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="songs.`+format+`"`)
+
+	count := 0
+	err = h.Stor.ExportSongs(r.Context(), filter, func(s model.Song) error {
+		count++
+		return write(s)
+	})
+	if err != nil {
+		// заголовки могли быть уже отправлены, поэтому ответ просто обрывается
+		h.Logger.Sugar.Infow("failed to export songs", "exported", count, "error", err)
+		if count == 0 {
+			// ответ еще не начат: ошибка не должна сохраниться у клиента как файл выгрузки
+			w.Header().Del("Content-Disposition")
+			http.Error(w, "Database error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := finish(); err != nil {
+		h.Logger.Sugar.Infow("failed to finish export", "error", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("songs exported", "format", format, "count", count)
+}
+
+// songRecord строка CSV с данными песни
+func songRecord(s model.Song) []string {
+	var releaseDate, enrichedAt string
+	if !s.ReleaseDate.Time.IsZero() {
+		releaseDate = s.ReleaseDate.String()
+	}
+	if s.EnrichedAt != nil {
+		enrichedAt = s.EnrichedAt.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.Itoa(s.ID), s.Group, s.Song, releaseDate, s.Text, s.Link, s.Lang,
+		strconv.FormatBool(s.Explicit), enrichedAt,
+	}
+}
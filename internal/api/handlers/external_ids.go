@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// SongByISRC возвращает песню по ISRC
+func (h *Handlers) SongByISRC(w http.ResponseWriter, r *http.Request) {
+	h.songByExternalID(w, r, model.SchemeISRC, chi.URLParam(r, "isrc"))
+}
+
+// SongByMBID возвращает песню по MusicBrainz recording ID
+func (h *Handlers) SongByMBID(w http.ResponseWriter, r *http.Request) {
+	h.songByExternalID(w, r, model.SchemeMBID, chi.URLParam(r, "mbid"))
+}
+
+// SongByExternalID возвращает песню по внешнему идентификатору любой схемы
+func (h *Handlers) SongByExternalID(w http.ResponseWriter, r *http.Request) {
+	h.songByExternalID(w, r, chi.URLParam(r, "scheme"), chi.URLParam(r, "value"))
+}
+
+func (h *Handlers) songByExternalID(w http.ResponseWriter, r *http.Request, scheme, value string) {
+	id, err := model.ParseExternalID(scheme, value)
+	if err != nil {
+		h.Logger.Sugar.Infow("invalid external id", "scheme", scheme, "value", value, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// значение уникально в пределах схемы, поэтому песня не больше одной
+	songs, err := h.Stor.GetSongs(r.Context(), &model.Filter{ExternalID: &id, Limit: 1})
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to fetch song", "scheme", id.Scheme, "value", id.Value, "error", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if len(songs) == 0 {
+		http.Error(w, "song not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(songs[0])
+}
+
+// AddExternalID добавляет песне внешний идентификатор
+func (h *Handlers) AddExternalID(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid song id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.ExternalID
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := model.ParseExternalID(req.Scheme, req.Value)
+	if err != nil {
+		h.Logger.Sugar.Infow("invalid external id", "scheme", req.Scheme, "value", req.Value, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Stor.AddExternalID(r.Context(), songID, id); err != nil {
+		h.Logger.Sugar.Infow("failed to add external id", "id", songID, "scheme", id.Scheme, "value", id.Value, "error", err)
+		http.Error(w, err.Error(), externalIDStatus(err))
+		return
+	}
+
+	h.Logger.Sugar.Infow("external id added", "id", songID, "scheme", id.Scheme, "value", id.Value)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(id)
+}
+
+// DeleteExternalID удаляет внешний идентификатор песни
+func (h *Handlers) DeleteExternalID(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid song id", http.StatusBadRequest)
+		return
+	}
+
+	id, err := model.ParseExternalID(chi.URLParam(r, "scheme"), chi.URLParam(r, "value"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Stor.DeleteExternalID(r.Context(), songID, id); err != nil {
+		h.Logger.Sugar.Infow("failed to delete external id", "id", songID, "scheme", id.Scheme, "value", id.Value, "error", err)
+		http.Error(w, err.Error(), externalIDStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// externalIDStatus возвращает код ответа для ошибки изменения внешних идентификаторов
+func externalIDStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrExternalIDExists):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrSongNotFound), errors.Is(err, storage.ErrExternalIDNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
+	"github.com/plasmatrip/muslib/internal/lang"
 	"github.com/plasmatrip/muslib/internal/model"
 )
 
@@ -29,7 +31,7 @@ func (h *Handlers) GetSongs(w http.ResponseWriter, r *http.Request) {
 	// Проверяем наличие песен
 	if len(songs) == 0 {
 		h.Logger.Sugar.Debugw("no songs found. filter:", "group", filter.Group,
-			"song", filter.Song, "text", filter.Text, "link", filter.Link, "release_from", filter.ReleaseFrom, "release_to", filter.ReleaseTo)
+			"song", filter.Song, "text", filter.Text, "link", filter.Link, "link_kind", filter.LinkKind, "broken_link", filter.BrokenLink, "lang", filter.Lang, "explicit", filter.Explicit, "release_from", filter.ReleaseFrom, "release_to", filter.ReleaseTo, "enriched_before", filter.EnrichedBefore)
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
@@ -62,22 +64,58 @@ func parseQueryParams(r *http.Request) (*model.Filter, error) {
 	if v := query.Get("link"); v != "" {
 		filter.Link = &v
 	}
+	if v := query.Get("link_kind"); v != "" {
+		if !model.ValidLinkKind(v) {
+			return nil, fmt.Errorf("unknown link kind %q", v)
+		}
+		filter.LinkKind = &v
+	}
+	if v := query.Get("broken_link"); v != "" {
+		broken, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+		filter.BrokenLink = &broken
+	}
+	if v := query.Get("lang"); v != "" {
+		v = strings.ToLower(v)
+		if !lang.Supported(v) {
+			return nil, fmt.Errorf("unknown language %q", v)
+		}
+		filter.Lang = &v
+	}
+	if v := query.Get("explicit"); v != "" {
+		explicit, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, err
+		}
+		filter.Explicit = &explicit
+	}
 
 	if v := query.Get("release_from"); v != "" {
-		t, err := time.Parse("02-01-2006", v)
+		rd, err := model.ParseReleaseDate(v)
 		if err != nil {
 			return nil, err
 		}
-		filter.ReleaseFrom = &t
+		filter.ReleaseFrom = &rd.Time
 	}
 	if v := query.Get("release_to"); v != "" {
-		t, err := time.Parse("02-01-2006", v)
+		rd, err := model.ParseReleaseDate(v)
 		if err != nil {
 			return nil, err
 		}
+		t := rd.End()
 		filter.ReleaseTo = &t
 	}
 
+	if v := query.Get("enriched_before"); v != "" {
+		rd, err := model.ParseReleaseDate(v)
+		if err != nil {
+			return nil, err
+		}
+		filter.EnrichedBefore = &rd.Time
+	}
+
 	if v := query.Get("limit"); v != "" {
 		limit, err := strconv.Atoi(v)
 		if err != nil || limit <= 0 {
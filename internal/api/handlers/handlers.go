@@ -1,26 +1,57 @@
 package handlers
 
 import (
-	"net/http"
-	"time"
+	"context"
 
 	"github.com/plasmatrip/muslib/internal/config"
+	"github.com/plasmatrip/muslib/internal/enrichment"
+	"github.com/plasmatrip/muslib/internal/infoservice"
 	"github.com/plasmatrip/muslib/internal/logger"
-	"github.com/plasmatrip/muslib/internal/storage"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/rating"
 )
 
-type Handlers struct {
-	Config config.Config
-	Logger logger.Logger
-	Stor   storage.Repository
-	Client http.Client
+// Storage методы хранилища, используемые обработчиками. Реализуется storage.Repository
+type Storage interface {
+	Ping(ctx context.Context) error
+
+	AddSongAndEnqueue(ctx context.Context, song model.Song, maxAttempts int) error
+	UpdateSong(ctx context.Context, song model.Song) error
+	DeleteSong(ctx context.Context, song model.Song) error
+	GetSong(ctx context.Context, id int) (model.Song, error)
+	GetSongs(ctx context.Context, filter *model.Filter) ([]model.Song, error)
+	GetSongIDs(ctx context.Context, filter *model.Filter) ([]int, error)
+	ExportSongs(ctx context.Context, filter *model.Filter, fn func(model.Song) error) error
+	ImportSongs(ctx context.Context, songs []model.Song, mode string, enrich bool, maxAttempts int) ([]model.ImportRowResult, error)
+	GetLyrics(ctx context.Context, song model.Song, verseNum int) (model.VerseResponse, error)
+	SetExplicitOverride(ctx context.Context, override model.ExplicitOverride) error
+
+	AddExternalID(ctx context.Context, songID int, id model.ExternalID) error
+	DeleteExternalID(ctx context.Context, songID int, id model.ExternalID) error
+	AddLink(ctx context.Context, songID int, link model.Link) error
+	DeleteLink(ctx context.Context, songID int, url string) error
+	GetLinkReport(ctx context.Context, limit, offset int) (model.LinkReport, error)
+
+	CreatePlaylist(ctx context.Context, p model.Playlist) (int, error)
+	UpdatePlaylist(ctx context.Context, p model.Playlist) error
+	DeletePlaylist(ctx context.Context, id int) error
+	GetPlaylists(ctx context.Context, limit, offset int) ([]model.Playlist, error)
+	GetPlaylist(ctx context.Context, id int) (model.Playlist, error)
+	PlaylistSongs(ctx context.Context, p model.Playlist) ([]model.Song, error)
+
+	EnqueueSongs(ctx context.Context, filter *model.Filter, maxAttempts int) (int64, error)
+	GetJobs(ctx context.Context, status string, limit, offset int) ([]model.Job, error)
+	RetryJob(ctx context.Context, id int64) error
+	RetryDeadJobs(ctx context.Context) (int64, error)
 }
 
-func NewHandlers(cfg config.Config, l logger.Logger, db storage.Repository) *Handlers {
-	return &Handlers{
-		Config: cfg,
-		Logger: l,
-		Stor:   db,
-		Client: http.Client{Timeout: cfg.ClientTimeout * time.Second},
-	}
+type Handlers struct {
+	Config      config.Config
+	Logger      logger.Logger
+	Stor        Storage
+	InfoService infoservice.Client
+	Cache       *infoservice.CacheClient
+	Limiter     *infoservice.LimitClient
+	Rater       *rating.Rater
+	Refresher   *enrichment.Refresher
 }
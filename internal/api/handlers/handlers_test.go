@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/rating"
+	"github.com/plasmatrip/muslib/internal/storage"
+	"go.uber.org/zap"
+)
+
+// fakeStorage хранилище песен в памяти. Методы, не нужные тестам, не реализованы
+// и при вызове паникуют через встроенный nil-интерфейс
+type fakeStorage struct {
+	Storage
+
+	mu        sync.Mutex
+	songs     []model.Song
+	filters   []model.Filter
+	playlists []model.Playlist
+
+	// exportErr, если задана, возвращается из ExportSongs после выгрузки песен
+	exportErr error
+}
+
+func (s *fakeStorage) AddSongAndEnqueue(_ context.Context, song model.Song, _ int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.songs {
+		if strings.EqualFold(existing.Group, song.Group) && strings.EqualFold(existing.Song, song.Song) {
+			return storage.ErrSongExists
+		}
+	}
+	song.ID = len(s.songs) + 1
+	s.songs = append(s.songs, song)
+	return nil
+}
+
+func (s *fakeStorage) GetSongs(_ context.Context, filter *model.Filter) ([]model.Song, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.filters = append(s.filters, *filter)
+
+	var songs []model.Song
+	for _, song := range s.songs {
+		if filter.Group == nil || strings.Contains(strings.ToLower(song.Group), strings.ToLower(*filter.Group)) {
+			songs = append(songs, song)
+		}
+	}
+	return songs, nil
+}
+
+func (s *fakeStorage) ExportSongs(_ context.Context, _ *model.Filter, fn func(model.Song) error) error {
+	s.mu.Lock()
+	songs := append([]model.Song(nil), s.songs...)
+	s.mu.Unlock()
+
+	for _, song := range songs {
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+	return s.exportErr
+}
+
+func (s *fakeStorage) CreatePlaylist(_ context.Context, p model.Playlist) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range p.SongIDs {
+		if id > len(s.songs) {
+			return 0, storage.ErrSongNotFound
+		}
+	}
+	p.ID = len(s.playlists) + 1
+	s.playlists = append(s.playlists, p)
+	return p.ID, nil
+}
+
+func (s *fakeStorage) GetPlaylist(_ context.Context, id int) (model.Playlist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id <= 0 || id > len(s.playlists) {
+		return model.Playlist{}, storage.ErrPlaylistNotFound
+	}
+	return s.playlists[id-1], nil
+}
+
+func (s *fakeStorage) PlaylistSongs(_ context.Context, p model.Playlist) ([]model.Song, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	songs := make([]model.Song, 0, len(p.SongIDs))
+	for _, id := range p.SongIDs {
+		songs = append(songs, s.songs[id-1])
+	}
+	return songs, nil
+}
+
+func newTestHandlers(t *testing.T, stor *fakeStorage, info *infoservice.Fake) *Handlers {
+	rater, err := rating.NewRater("")
+	if err != nil {
+		t.Fatalf("failed to load word lists: %v", err)
+	}
+
+	return &Handlers{
+		Logger:      logger.Logger{Sugar: zap.NewNop().Sugar()},
+		Stor:        stor,
+		InfoService: info,
+		Rater:       rater,
+	}
+}
+
+func TestAddSong(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "added", body: `{"group":"  Muse ","song":"Supermassive   Black Hole"}`, status: http.StatusAccepted},
+		{name: "with lyrics", body: `{"group":"Кино","song":"Пачка сигарет","text":"Я сижу и смотрю в чужое небо из чужого окна,\nи не вижу ни одной знакомой звезды, блять"}`, status: http.StatusAccepted},
+		{name: "duplicate in other case", body: `{"group":"muse","song":"supermassive black hole"}`, status: http.StatusConflict},
+		{name: "empty song", body: `{"group":"Muse","song":"  "}`, status: http.StatusBadRequest},
+		{name: "malformed json", body: `{"group":`, status: http.StatusBadRequest},
+		{name: "invalid link", body: `{"group":"Muse","song":"Uprising","link":"ftp://example.com/a"}`, status: http.StatusBadRequest},
+		{name: "long link", body: `{"group":"Muse","song":"Resistance","link":"https://example.com/` + strings.Repeat("a", 300) + `"}`, status: http.StatusAccepted},
+		{name: "too long link", body: `{"group":"Muse","song":"Uprising","link":"https://example.com/?q=` + strings.Repeat("a", 2048) + `"}`, status: http.StatusBadRequest},
+		{name: "invalid external id", body: `{"group":"Muse","song":"Uprising","external_ids":[{"scheme":"isrc","value":"bad"}]}`, status: http.StatusBadRequest},
+	}
+
+	stor := &fakeStorage{}
+	info := infoservice.NewFake()
+	h := newTestHandlers(t, stor, info)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.AddSong(w, httptest.NewRequest(http.MethodPost, "/songs", strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.status, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+
+	if len(stor.songs) != 3 {
+		t.Fatalf("stored %d songs, want 3", len(stor.songs))
+	}
+	if got := stor.songs[0]; got.Group != "Muse" || got.Song != "Supermassive Black Hole" {
+		t.Errorf("stored song %q - %q, want normalized names", got.Group, got.Song)
+	}
+	// язык и признак ненормативной лексики определяются по переданному тексту сразу
+	if got := stor.songs[1]; got.Lang != "ru" || !got.Explicit || got.ExplicitTerms["блять"] != 1 {
+		t.Errorf("stored lang %q, explicit %v, terms %v, want ru, true, блять", got.Lang, got.Explicit, got.ExplicitTerms)
+	}
+	// данные внешнего сервиса получает фоновое задание, а не обработчик
+	if calls := info.Calls(); calls != 0 {
+		t.Errorf("info service called %d times during request", calls)
+	}
+}
+
+func TestGetSongs(t *testing.T) {
+	stor := &fakeStorage{songs: []model.Song{
+		{ID: 1, Group: "Muse", Song: "Uprising"},
+		{ID: 2, Group: "Кино", Song: "Группа крови"},
+	}}
+	h := newTestHandlers(t, stor, infoservice.NewFake())
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+		ids    []int
+	}{
+		{name: "all", query: "", status: http.StatusOK, ids: []int{1, 2}},
+		{name: "by group", query: "?group=mus", status: http.StatusOK, ids: []int{1}},
+		{name: "nothing found", query: "?group=queen", status: http.StatusNoContent},
+		{name: "invalid link kind", query: "?link_kind=cassette", status: http.StatusBadRequest},
+		{name: "invalid explicit", query: "?explicit=maybe", status: http.StatusBadRequest},
+		{name: "unknown language", query: "?lang=xx", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.GetSongs(w, httptest.NewRequest(http.MethodGet, "/songs"+tt.query, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.status, strings.TrimSpace(w.Body.String()))
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var songs []model.Song
+			if err := json.NewDecoder(w.Body).Decode(&songs); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if len(songs) != len(tt.ids) {
+				t.Fatalf("got %d songs, want %d", len(songs), len(tt.ids))
+			}
+			for i, song := range songs {
+				if song.ID != tt.ids[i] {
+					t.Errorf("song %d has id %d, want %d", i, song.ID, tt.ids[i])
+				}
+			}
+		})
+	}
+
+	// без параметров пагинации используется страница по умолчанию
+	if f := stor.filters[0]; f.Limit != 10 || f.Page != 0 {
+		t.Errorf("default pagination = limit %d page %d, want limit 10 page 0", f.Limit, f.Page)
+	}
+}
+
+func TestExportSongs(t *testing.T) {
+	song := model.Song{ID: 1, Group: "Muse", Song: "Uprising"}
+
+	tests := []struct {
+		name        string
+		songs       []model.Song
+		err         error
+		status      int
+		disposition bool
+	}{
+		{name: "exported", songs: []model.Song{song}, status: http.StatusOK, disposition: true},
+		{name: "empty library", status: http.StatusOK, disposition: true},
+		{name: "error before first song", err: errors.New("connection reset"), status: http.StatusInternalServerError},
+		// ответ уже начат, поэтому код ответа не меняется
+		{name: "error after first song", songs: []model.Song{song}, err: errors.New("connection reset"), status: http.StatusOK, disposition: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandlers(t, &fakeStorage{songs: tt.songs, exportErr: tt.err}, infoservice.NewFake())
+
+			w := httptest.NewRecorder()
+			h.ExportSongs(w, httptest.NewRequest(http.MethodGet, "/songs/export?format=csv", nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.status, strings.TrimSpace(w.Body.String()))
+			}
+			if got := w.Header().Get("Content-Disposition") != ""; got != tt.disposition {
+				t.Errorf("Content-Disposition set = %v, want %v", got, tt.disposition)
+			}
+		})
+	}
+}
+
+func TestPlaylists(t *testing.T) {
+	stor := &fakeStorage{songs: []model.Song{
+		{ID: 1, Group: "Muse", Song: "Uprising", SongDetail: model.SongDetail{Link: "https://www.youtube.com/watch?v=w8KQmps-Sog"}},
+		{ID: 2, Group: "Кино", Song: "Группа крови", SongDetail: model.SongDetail{Link: "https://www.youtube.com/watch?v=3vFqF0-Pdeo"}},
+	}}
+	h := newTestHandlers(t, stor, infoservice.NewFake())
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "created", body: `{"title":" Утренний эфир ","song_ids":[2,1,2]}`, status: http.StatusCreated},
+		{name: "empty title", body: `{"title":" ","song_ids":[1]}`, status: http.StatusBadRequest},
+		{name: "long title", body: `{"title":"` + strings.Repeat("я", maxPlaylistTitle+1) + `"}`, status: http.StatusBadRequest},
+		{name: "invalid song id", body: `{"title":"a","song_ids":[0]}`, status: http.StatusBadRequest},
+		{name: "unknown song", body: `{"title":"a","song_ids":[1,3]}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.CreatePlaylist(w, httptest.NewRequest(http.MethodPost, "/playlists", strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.status, strings.TrimSpace(w.Body.String()))
+			}
+		})
+	}
+
+	if len(stor.playlists) != 1 || stor.playlists[0].Title != "Утренний эфир" {
+		t.Fatalf("stored playlists %+v, want one with trimmed title", stor.playlists)
+	}
+
+	r := chi.NewRouter()
+	r.Get("/playlists/{id}/export", h.ExportPlaylist)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/playlists/1/export", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("export status = %d, want 200", w.Code)
+	}
+	// песни выгружаются в порядке плейлиста, повторы сохраняются
+	var urls []string
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		if strings.HasPrefix(line, "https://") {
+			urls = append(urls, line)
+		}
+	}
+	want := []string{stor.songs[1].Link, stor.songs[0].Link, stor.songs[1].Link}
+	if strings.Join(urls, " ") != strings.Join(want, " ") {
+		t.Errorf("exported %v, want %v", urls, want)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/playlists/2/export", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown playlist status = %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/plasmatrip/muslib/internal/importer"
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// importBatchSize количество песен в одном пакете запросов к БД
+const importBatchSize = 500
+
+// ImportSongs добавляет песни из JSON-массива, NDJSON или CSV (по Content-Type).
+// Параметры: mode - insert, upsert или skip для существующих песен (по умолчанию insert),
+// enrich - ставить ли задания на получение данных для добавленных песен (по умолчанию true).
+// Возвращает отчет с результатом каждой строки
+func (h *Handlers) ImportSongs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	if format == "" {
+		var err error
+		if format, err = importer.FormatFromContentType(r.Header.Get("Content-Type")); err != nil {
+			h.Logger.Sugar.Infow("unsupported import format", "error", err)
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+	}
+
+	mode := query.Get("mode")
+	switch mode {
+	case "":
+		mode = model.ImportInsert
+	case model.ImportInsert, model.ImportUpsert, model.ImportSkip:
+	default:
+		http.Error(w, "invalid import mode", http.StatusBadRequest)
+		return
+	}
+
+	enrich := true
+	if v := query.Get("enrich"); v != "" {
+		var err error
+		if enrich, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+	}
+
+	reader, err := importer.NewReader(r.Body, format)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to read import", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// результаты строк в порядке чтения; строки, ожидающие записи в БД,
+	// заполняются после выполнения пакета
+	var (
+		rows    []model.ImportRowResult
+		pending []int
+		songs   []model.Song
+	)
+
+	flush := func() {
+		if len(songs) == 0 {
+			return
+		}
+
+		results, err := h.Stor.ImportSongs(r.Context(), songs, mode, enrich, h.Config.EnrichMaxAttempts)
+		for i, idx := range pending {
+			if err != nil {
+				rows[idx].Status = model.ImportFailed
+				rows[idx].Error = "database error"
+				continue
+			}
+			results[i].Line = rows[idx].Line
+			rows[idx] = results[i]
+		}
+		if err != nil {
+			h.Logger.Sugar.Infow("failed to import batch", "error", err)
+		}
+
+		songs = songs[:0]
+		pending = pending[:0]
+	}
+
+	var fatal error
+	for r.Context().Err() == nil {
+		line, rec, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var rowErr *importer.RowError
+		if errors.As(err, &rowErr) {
+			rows = append(rows, model.ImportRowResult{Line: line, Status: model.ImportFailed, Error: rowErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			fatal = err
+			break
+		}
+
+		song, err := rec.ToSong(h.Rater)
+		if err != nil {
+			rows = append(rows, model.ImportRowResult{Line: line, Group: song.Group, Song: song.Song, Status: model.ImportFailed, Error: err.Error()})
+			continue
+		}
+
+		pending = append(pending, len(rows))
+		rows = append(rows, model.ImportRowResult{Line: line, Group: song.Group, Song: song.Song})
+		songs = append(songs, song)
+
+		if len(songs) >= importBatchSize {
+			flush()
+		}
+	}
+	if r.Context().Err() != nil {
+		return
+	}
+	flush()
+
+	report := model.ImportReport{Rows: make([]model.ImportRowResult, 0, len(rows))}
+	for _, row := range rows {
+		report.Add(row)
+	}
+
+	status := http.StatusOK
+	if fatal != nil {
+		// данные, прочитанные до ошибки разметки, уже сохранены
+		report.Error = fatal.Error()
+		status = http.StatusBadRequest
+	}
+
+	h.Logger.Sugar.Infow("songs imported", "format", format, "mode", mode, "total", report.Total, "inserted", report.Inserted,
+		"updated", report.Updated, "skipped", report.Skipped, "failed", report.Failed, "error", fatal)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
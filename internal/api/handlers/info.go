@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 )
 
@@ -16,3 +17,25 @@ func (h *Handlers) Info(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusOK)
 }
+
+// CacheStats возвращает статистику кэша ответов внешнего сервиса
+func (h *Handlers) CacheStats(w http.ResponseWriter, r *http.Request) {
+	if h.Cache == nil {
+		http.Error(w, "cache disabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Cache.Stats())
+}
+
+// LimiterStats возвращает статистику ограничителя запросов к внешнему сервису
+func (h *Handlers) LimiterStats(w http.ResponseWriter, r *http.Request) {
+	if h.Limiter == nil {
+		http.Error(w, "limiter disabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Limiter.Stats())
+}
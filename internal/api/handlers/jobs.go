@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// GetJobs возвращает задания на получение данных из внешнего сервиса
+func (h *Handlers) GetJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	switch status {
+	case "", model.JobPending, model.JobRunning, model.JobDone, model.JobDead:
+	default:
+		h.Logger.Sugar.Infow("invalid job status", "status", status)
+		http.Error(w, "invalid job status", http.StatusBadRequest)
+		return
+	}
+
+	limit, page := 10, 0
+	if v := query.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+	if v := query.Get("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+		page = p
+	}
+
+	jobs, err := h.Stor.GetJobs(r.Context(), status, limit, page*limit)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to fetch jobs", "error", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	if len(jobs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// RetryJob перезапускает задание в статусе dead
+func (h *Handlers) RetryJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.Logger.Sugar.Infow("invalid job id", "id", chi.URLParam(r, "id"))
+		http.Error(w, "invalid job id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Stor.RetryJob(r.Context(), id); err != nil {
+		h.Logger.Sugar.Infow("failed to retry job", "id", id, "error", err)
+		if errors.Is(err, storage.ErrJobNotRetryable) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "error processing request", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Sugar.Infow("job retried", "id", id)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RetryDeadJobs перезапускает все задания в статусе dead
+func (h *Handlers) RetryDeadJobs(w http.ResponseWriter, r *http.Request) {
+	count, err := h.Stor.RetryDeadJobs(r.Context())
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to retry jobs", "error", err)
+		http.Error(w, "error processing request", http.StatusInternalServerError)
+		return
+	}
+
+	h.Logger.Sugar.Infow("dead jobs retried", "count", count)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"retried": count})
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plasmatrip/muslib/internal/links"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// AddLink добавляет песне ссылку. Тип определяется по адресу, если не указан явно
+func (h *Handlers) AddLink(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid song id", http.StatusBadRequest)
+		return
+	}
+
+	var req model.Link
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.Sugar.Infow("error in request handler", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	link, err := links.Parse(req.URL, req.Kind)
+	if err != nil {
+		h.Logger.Sugar.Infow("invalid link", "id", songID, "url", req.URL, "kind", req.Kind, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Stor.AddLink(r.Context(), songID, link); err != nil {
+		h.Logger.Sugar.Infow("failed to add link", "id", songID, "url", link.URL, "error", err)
+		http.Error(w, err.Error(), linkStatus(err))
+		return
+	}
+
+	h.Logger.Sugar.Infow("link added", "id", songID, "url", link.URL, "kind", link.Kind)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// DeleteLink удаляет ссылку песни, указанную параметром url
+func (h *Handlers) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid song id", http.StatusBadRequest)
+		return
+	}
+
+	// ссылка хранится в каноническом виде, поэтому удалить ее можно по любой записи
+	link, err := links.Parse(r.URL.Query().Get("url"), "")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Stor.DeleteLink(r.Context(), songID, link.URL); err != nil {
+		h.Logger.Sugar.Infow("failed to delete link", "id", songID, "url", link.URL, "error", err)
+		http.Error(w, err.Error(), linkStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// linkStatus возвращает код ответа для ошибки изменения ссылок
+func linkStatus(err error) int {
+	if errors.Is(err, storage.ErrSongNotFound) || errors.Is(err, storage.ErrLinkNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// LinkReport возвращает сводку проверки доступности ссылок и недоступные ссылки
+func (h *Handlers) LinkReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, page := 100, 0
+	if v := query.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+	if v := query.Get("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+		page = p
+	}
+
+	report, err := h.Stor.GetLinkReport(r.Context(), limit, page*limit)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to build link report", "error", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -21,6 +21,7 @@ func (h *Handlers) GetLyrics(w http.ResponseWriter, r *http.Request) {
 	if v := query.Get("song"); v != "" {
 		song.Song = v
 	}
+	song.Normalize()
 
 	verseNumStr := r.URL.Query().Get("verse")
 
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/playlist"
+)
+
+// GetPlaylist возвращает песни, отобранные фильтрами GET /songs, в виде плейлиста
+// формата format: m3u (по умолчанию), xspf или jspf. Параметр title задает название плейлиста
+func (h *Handlers) GetPlaylist(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseQueryParams(r)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to parse query params", "error", err)
+		http.Error(w, "invalid query parameters", http.StatusBadRequest)
+		return
+	}
+
+	format, ok := playlistFormat(w, r)
+	if !ok {
+		return
+	}
+
+	songs, err := h.Stor.GetSongs(r.Context(), filter)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to fetch songs", "error", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writePlaylist(w, format, r.URL.Query().Get("title"), songs)
+}
+
+// playlistFormat возвращает формат плейлиста из параметра format или отвечает 400
+func playlistFormat(w http.ResponseWriter, r *http.Request) (string, bool) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = playlist.FormatM3U
+	}
+	if _, ok := playlist.ContentTypes[format]; !ok {
+		http.Error(w, "invalid playlist format", http.StatusBadRequest)
+		return "", false
+	}
+	return format, true
+}
+
+// writePlaylist отправляет песни плейлистом в формате format
+func (h *Handlers) writePlaylist(w http.ResponseWriter, format, title string, songs []model.Song) {
+	w.Header().Set("Content-Type", playlist.ContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="playlist.`+format+`"`)
+
+	if err := playlist.Write(w, format, title, songs); err != nil {
+		h.Logger.Sugar.Infow("failed to write playlist", "format", format, "error", err)
+		return
+	}
+
+	h.Logger.Sugar.Infow("playlist exported", "format", format, "count", len(songs))
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+const (
+	// maxPlaylistTitle максимальная длина названия плейлиста в символах
+	maxPlaylistTitle = 255
+	// maxPlaylistSongs максимальное количество песен в плейлисте
+	maxPlaylistSongs = 1000
+)
+
+// CreatePlaylist сохраняет плейлист из песен библиотеки
+func (h *Handlers) CreatePlaylist(w http.ResponseWriter, r *http.Request) {
+	p, err := decodePlaylist(r)
+	if err != nil {
+		h.Logger.Sugar.Infow("invalid playlist", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.Stor.CreatePlaylist(r.Context(), p)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to create playlist", "title", p.Title, "error", err)
+		http.Error(w, playlistError(err), playlistStatus(err))
+		return
+	}
+
+	h.Logger.Sugar.Infow("playlist created", "id", id, "title", p.Title, "count", len(p.SongIDs))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int{"id": id})
+}
+
+// UpdatePlaylist заменяет название и песни плейлиста
+func (h *Handlers) UpdatePlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid playlist id", http.StatusBadRequest)
+		return
+	}
+
+	p, err := decodePlaylist(r)
+	if err != nil {
+		h.Logger.Sugar.Infow("invalid playlist", "id", id, "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.ID = id
+
+	if err := h.Stor.UpdatePlaylist(r.Context(), p); err != nil {
+		h.Logger.Sugar.Infow("failed to update playlist", "id", id, "error", err)
+		http.Error(w, playlistError(err), playlistStatus(err))
+		return
+	}
+
+	h.Logger.Sugar.Infow("playlist updated", "id", id, "count", len(p.SongIDs))
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeletePlaylist удаляет плейлист
+func (h *Handlers) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid playlist id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Stor.DeletePlaylist(r.Context(), id); err != nil {
+		h.Logger.Sugar.Infow("failed to delete playlist", "id", id, "error", err)
+		http.Error(w, playlistError(err), playlistStatus(err))
+		return
+	}
+
+	h.Logger.Sugar.Infow("playlist deleted", "id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPlaylists возвращает сохраненные плейлисты с пагинацией
+func (h *Handlers) GetPlaylists(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, page := 10, 0
+	if v := query.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l <= 0 {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+		limit = l
+	}
+	if v := query.Get("page"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil || p < 0 {
+			http.Error(w, "invalid query parameters", http.StatusBadRequest)
+			return
+		}
+		page = p
+	}
+
+	playlists, err := h.Stor.GetPlaylists(r.Context(), limit, page*limit)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to fetch playlists", "error", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(playlists)
+}
+
+// SavedPlaylist возвращает сохраненный плейлист
+func (h *Handlers) SavedPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid playlist id", http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.Stor.GetPlaylist(r.Context(), id)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to fetch playlist", "id", id, "error", err)
+		http.Error(w, playlistError(err), playlistStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// ExportPlaylist возвращает песни сохраненного плейлиста в формате format: m3u (по умолчанию), xspf или jspf.
+// Песни, удаленные из библиотеки, в плейлист не попадают
+func (h *Handlers) ExportPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "invalid playlist id", http.StatusBadRequest)
+		return
+	}
+
+	format, ok := playlistFormat(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := h.Stor.GetPlaylist(r.Context(), id)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to fetch playlist", "id", id, "error", err)
+		http.Error(w, playlistError(err), playlistStatus(err))
+		return
+	}
+
+	songs, err := h.Stor.PlaylistSongs(r.Context(), p)
+	if err != nil {
+		h.Logger.Sugar.Infow("failed to fetch playlist songs", "id", id, "error", err)
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+
+	h.writePlaylist(w, format, p.Title, songs)
+}
+
+// decodePlaylist разбирает и проверяет плейлист из тела запроса
+func decodePlaylist(r *http.Request) (model.Playlist, error) {
+	var p model.Playlist
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		return p, err
+	}
+
+	p.Title = strings.TrimSpace(p.Title)
+	if p.Title == "" {
+		return p, errors.New("empty playlist title")
+	}
+	if utf8.RuneCountInString(p.Title) > maxPlaylistTitle {
+		return p, fmt.Errorf("playlist title is longer than %d characters", maxPlaylistTitle)
+	}
+	if len(p.SongIDs) > maxPlaylistSongs {
+		return p, fmt.Errorf("playlist has more than %d songs", maxPlaylistSongs)
+	}
+	for _, id := range p.SongIDs {
+		if id <= 0 {
+			return p, fmt.Errorf("invalid song id %d", id)
+		}
+	}
+
+	return p, nil
+}
+
+// playlistStatus возвращает код ответа для ошибки работы с плейлистом
+func playlistStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrPlaylistNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrSongNotFound):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// playlistError возвращает текст ответа для ошибки работы с плейлистом
+func playlistError(err error) string {
+	if playlistStatus(err) == http.StatusInternalServerError {
+		return "error processing request"
+	}
+	return err.Error()
+}
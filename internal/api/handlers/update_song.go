@@ -5,6 +5,8 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/plasmatrip/muslib/internal/lang"
+	"github.com/plasmatrip/muslib/internal/links"
 	"github.com/plasmatrip/muslib/internal/model"
 )
 
@@ -19,6 +21,9 @@ func (h *Handlers) UpdateSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Приводим названия к каноническому виду
+	song.Normalize()
+
 	// Проверяем параметры
 	if len(song.Song) == 0 || len(song.Group) == 0 {
 		h.Logger.Sugar.Infow("error update song", "error", errors.New("empty group name or song name"))
@@ -26,6 +31,21 @@ func (h *Handlers) UpdateSong(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Проверяем ссылку
+	if song.Link != "" {
+		if _, err := links.Parse(song.Link, ""); err != nil {
+			h.Logger.Sugar.Infow("invalid link", "link", song.Link, "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Определяем язык текста, если он изменился
+	song.Lang = lang.Detect(song.Text)
+
+	// Проверяем текст на ненормативную лексику
+	h.Rater.RateSong(&song)
+
 	// Обновляем песню
 	if err := h.Stor.UpdateSong(r.Context(), song); err != nil {
 		h.Logger.Sugar.Infow("failed to update song", "error", err)
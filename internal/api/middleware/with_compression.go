@@ -35,6 +35,16 @@ func (c *compressWriter) WriteHeader(statusCode int) {
 	c.w.WriteHeader(statusCode)
 }
 
+// Flush отправляет клиенту уже сжатые данные, не дожидаясь конца ответа
+func (c *compressWriter) Flush() {
+	if err := c.zw.Flush(); err != nil {
+		return
+	}
+	if f, ok := c.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (c *compressWriter) Close() error {
 	return c.zw.Close()
 }
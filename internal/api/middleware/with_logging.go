@@ -30,6 +30,13 @@ func (r *loggingResponseWriter) WriteHeader(status int) {
 	r.responseData.status = status
 }
 
+// Flush передает буферизованные данные клиенту, если это поддерживает исходный ResponseWriter
+func (r *loggingResponseWriter) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // WithLogging устанавливает логирование
 func WithLogging(log logger.Logger) func(next http.Handler) http.Handler {
 	log.Sugar.Debug("handler logging started")
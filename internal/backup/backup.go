@@ -0,0 +1,275 @@
+// Package backup описывает формат резервной копии библиотеки: zip-архив с манифестом
+// manifest.json и содержимым каждой таблицы в виде NDJSON (tables/<таблица>.ndjson)
+package backup
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+// FormatVersion версия формата архива. Увеличивается при несовместимых изменениях
+const FormatVersion = 1
+
+// app отметка приложения в манифесте
+const app = "muslib"
+
+// manifestName имя манифеста в архиве
+const manifestName = "manifest.json"
+
+// maxRowSize максимальный размер строки таблицы в архиве
+const maxRowSize = 64 << 20
+
+// ErrInvalidArchive возвращается для поврежденного или чужого архива
+var ErrInvalidArchive = errors.New("invalid backup archive")
+
+// Manifest описание резервной копии
+type Manifest struct {
+	App           string    `json:"app"`
+	FormatVersion int       `json:"format_version"`
+	SchemaVersion uint      `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	Tables        []Table   `json:"tables"`
+}
+
+// Table таблица в архиве. Таблицы перечислены в порядке загрузки
+type Table struct {
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	Rows   int64  `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// Writer записывает резервную копию. Реализует storage.Dumper
+type Writer struct {
+	zw       *zip.Writer
+	manifest Manifest
+	current  *Table
+	w        io.Writer
+	hash     hash.Hash
+}
+
+// NewWriter создает запись архива в w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		zw:       zip.NewWriter(w),
+		manifest: Manifest{App: app, FormatVersion: FormatVersion, CreatedAt: time.Now().UTC()},
+	}
+}
+
+// Table начинает файл очередной таблицы
+func (w *Writer) Table(name string) error {
+	w.finishTable()
+
+	f, err := w.zw.CreateHeader(&zip.FileHeader{
+		Name:     "tables/" + name + ".ndjson",
+		Method:   zip.Deflate,
+		Modified: w.manifest.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	w.manifest.Tables = append(w.manifest.Tables, Table{Name: name, File: "tables/" + name + ".ndjson"})
+	w.current = &w.manifest.Tables[len(w.manifest.Tables)-1]
+	w.hash = sha256.New()
+	w.w = io.MultiWriter(f, w.hash)
+
+	return nil
+}
+
+// Row записывает строку текущей таблицы
+func (w *Writer) Row(data []byte) error {
+	if w.current == nil {
+		return errors.New("row written before table")
+	}
+	if _, err := w.w.Write(data); err != nil {
+		return err
+	}
+	if _, err := w.w.Write([]byte{'\n'}); err != nil {
+		return err
+	}
+	w.current.Rows++
+	return nil
+}
+
+// Close записывает манифест с версией схемы и завершает архив
+func (w *Writer) Close(schemaVersion uint) (Manifest, error) {
+	w.finishTable()
+	w.manifest.SchemaVersion = schemaVersion
+
+	f, err := w.zw.CreateHeader(&zip.FileHeader{
+		Name:     manifestName,
+		Method:   zip.Deflate,
+		Modified: w.manifest.CreatedAt,
+	})
+	if err != nil {
+		return w.manifest, err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(w.manifest); err != nil {
+		return w.manifest, err
+	}
+
+	return w.manifest, w.zw.Close()
+}
+
+// finishTable запоминает контрольную сумму текущей таблицы
+func (w *Writer) finishTable() {
+	if w.current != nil {
+		w.current.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+		w.current = nil
+	}
+}
+
+// Archive открытая резервная копия
+type Archive struct {
+	zr       *zip.ReadCloser
+	files    map[string]*zip.File
+	Manifest Manifest
+}
+
+// Open открывает архив и читает манифест
+func Open(path string) (*Archive, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+
+	a := &Archive{zr: zr, files: make(map[string]*zip.File, len(zr.File))}
+	for _, f := range zr.File {
+		a.files[f.Name] = f
+	}
+
+	if err := a.readManifest(); err != nil {
+		zr.Close()
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// readManifest читает и проверяет манифест
+func (a *Archive) readManifest() error {
+	f, ok := a.files[manifestName]
+	if !ok {
+		return fmt.Errorf("%w: %s not found", ErrInvalidArchive, manifestName)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(rc).Decode(&a.Manifest); err != nil {
+		return fmt.Errorf("%w: malformed manifest: %w", ErrInvalidArchive, err)
+	}
+
+	m := a.Manifest
+	if m.App != app {
+		return fmt.Errorf("%w: not a muslib backup", ErrInvalidArchive)
+	}
+	if m.FormatVersion != FormatVersion {
+		return fmt.Errorf("%w: unsupported format version %d, expected %d", ErrInvalidArchive, m.FormatVersion, FormatVersion)
+	}
+	seen := make(map[string]bool, len(m.Tables))
+	for _, t := range m.Tables {
+		if t.Name == "" || seen[t.Name] {
+			return fmt.Errorf("%w: empty or duplicate table name %q", ErrInvalidArchive, t.Name)
+		}
+		seen[t.Name] = true
+		if _, ok := a.files[t.File]; !ok {
+			return fmt.Errorf("%w: file %s of table %s not found", ErrInvalidArchive, t.File, t.Name)
+		}
+	}
+
+	return nil
+}
+
+// TableNames возвращает таблицы архива в порядке загрузки
+func (a *Archive) TableNames() []string {
+	names := make([]string, 0, len(a.Manifest.Tables))
+	for _, t := range a.Manifest.Tables {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// Verify проверяет контрольные суммы и количество строк всех таблиц,
+// а также то, что каждая строка - JSON-объект
+func (a *Archive) Verify() error {
+	for _, t := range a.Manifest.Tables {
+		if err := a.Rows(t.Name, func(data []byte) error {
+			if len(data) == 0 || data[0] != '{' || !json.Valid(data) {
+				return errors.New("row is not a JSON object")
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rows передает в fn строки таблицы. После чтения проверяются количество строк и контрольная сумма
+func (a *Archive) Rows(table string, fn func([]byte) error) error {
+	var t *Table
+	for i := range a.Manifest.Tables {
+		if a.Manifest.Tables[i].Name == table {
+			t = &a.Manifest.Tables[i]
+		}
+	}
+	if t == nil {
+		return fmt.Errorf("%w: table %s not found", ErrInvalidArchive, table)
+	}
+
+	rc, err := a.files[t.File].Open()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+	defer rc.Close()
+
+	h := sha256.New()
+	scanner := bufio.NewScanner(io.TeeReader(rc, h))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRowSize)
+
+	var rows int64
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		rows++
+		if err := fn(line); err != nil {
+			return fmt.Errorf("table %s, row %d: %w", table, rows, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w: table %s: %w", ErrInvalidArchive, table, err)
+	}
+
+	if rows != t.Rows {
+		return fmt.Errorf("%w: table %s has %d rows, manifest says %d", ErrInvalidArchive, table, rows, t.Rows)
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); sum != t.SHA256 {
+		return fmt.Errorf("%w: checksum mismatch for table %s", ErrInvalidArchive, table)
+	}
+
+	return nil
+}
+
+// Close закрывает архив
+func (a *Archive) Close() error {
+	return a.zr.Close()
+}
@@ -11,19 +11,121 @@ import (
 	"github.com/joho/godotenv"
 )
 
-const clientTimeout = time.Second * 5 //таймаут запроса к внешнему сервису
+const (
+	clientTimeout    = time.Second * 5        //таймаут запроса к внешнему сервису
+	retryMaxAttempts = 3                      //количество попыток запроса к внешнему сервису
+	retryBaseDelay   = time.Millisecond * 200 //задержка перед первым повтором
+	retryMaxDelay    = time.Second * 5        //максимальная задержка между попытками
+	retryJitter      = 0.5                    //доля случайного разброса задержки
+
+	breakerFailureThreshold = 5                //количество ошибок подряд для размыкания предохранителя
+	breakerOpenTimeout      = time.Second * 30 //время до пробных запросов после размыкания
+	breakerHalfOpenSuccess  = 1                //количество успешных пробных запросов для замыкания
+	enrichWorkers           = 4                //количество обработчиков очереди заданий
+	enrichInterval          = time.Second * 2  //период опроса пустой очереди заданий
+	enrichMaxAttempts       = 5                //количество попыток выполнения задания
+	enrichRetryDelay        = time.Second * 30 //задержка перед первым повтором задания
+	enrichMaxRetryDelay     = time.Hour        //максимальная задержка перед повтором задания
+
+	infoCacheSize        = 10000          //количество ответов внешнего сервиса в кэше
+	infoCacheTTL         = time.Hour * 24 //время жизни ответа в кэше
+	infoCacheNegativeTTL = time.Hour      //время жизни ответа "песня не найдена" в кэше
+
+	infoRateLimit   = 10 //количество запросов к внешнему сервису в секунду
+	infoRateBurst   = 10 //количество запросов к внешнему сервису, выполняемых сразу после простоя
+	infoMaxInFlight = 10 //количество одновременных запросов к внешнему сервису
+
+	linkCheckWorkers      = 4                //количество одновременных проверок ссылок
+	linkCheckInterval     = time.Minute      //период поиска ссылок для проверки
+	linkCheckMaxAge       = time.Hour * 24   //через сколько ссылка проверяется повторно
+	linkCheckHostDelay    = time.Second      //минимальный интервал между запросами к одному сайту
+	linkCheckTimeout      = time.Second * 10 //таймаут проверки ссылки
+	linkCheckMaxRedirects = 5                //максимальное количество перенаправлений
+	linkCheckFailures     = 2                //количество неудачных проверок подряд, после которого ссылка недоступна
+)
 
 type Config struct {
 	Host          string        `env:"RUN_ADDRESS"`          //адрес веб-сервера
 	Database      string        `env:"DATABASE_URI"`         //DSN базы данных
 	InfoService   string        `env:"INFO_SERVICE_ADDRESS"` //адрес внешнего сервиса
 	LogLevel      string        `env:"LOG_LEVEL"`            //уровень логирования
+	ExplicitWords string        `env:"EXPLICIT_WORDS_DIR"`   //каталог со списками ненормативной лексики (необязательно)
+	AutoMigrate   bool          `env:"AUTO_MIGRATE"`         //применять миграции схемы БД при запуске
 	ClientTimeout time.Duration //таймаут запроса к внешнему сервису
+
+	RetryMaxAttempts int           `env:"INFO_RETRY_MAX_ATTEMPTS"` //количество попыток запроса к внешнему сервису
+	RetryBaseDelay   time.Duration `env:"INFO_RETRY_BASE_DELAY"`   //задержка перед первым повтором
+	RetryMaxDelay    time.Duration `env:"INFO_RETRY_MAX_DELAY"`    //максимальная задержка между попытками
+	RetryJitter      float64       `env:"INFO_RETRY_JITTER"`       //доля случайного разброса задержки (0..1)
+
+	BreakerFailureThreshold int           `env:"INFO_BREAKER_FAILURES"`     //количество ошибок подряд для размыкания предохранителя
+	BreakerOpenTimeout      time.Duration `env:"INFO_BREAKER_OPEN_TIMEOUT"` //время до пробных запросов после размыкания
+	BreakerHalfOpenSuccess  int           `env:"INFO_BREAKER_HALF_OPEN_OK"` //количество успешных пробных запросов для замыкания
+	EnrichWorkers           int           `env:"ENRICH_WORKERS"`            //количество обработчиков очереди заданий
+	EnrichInterval          time.Duration `env:"ENRICH_INTERVAL"`           //период опроса пустой очереди заданий
+	EnrichMaxAttempts       int           `env:"ENRICH_MAX_ATTEMPTS"`       //количество попыток выполнения задания
+	EnrichRetryDelay        time.Duration `env:"ENRICH_RETRY_DELAY"`        //задержка перед первым повтором задания
+	EnrichMaxRetryDelay     time.Duration `env:"ENRICH_MAX_RETRY_DELAY"`    //максимальная задержка перед повтором задания
+
+	InfoCacheSize        int           `env:"INFO_CACHE_SIZE"`         //количество ответов внешнего сервиса в кэше (0 - без кэша в памяти)
+	InfoCacheTTL         time.Duration `env:"INFO_CACHE_TTL"`          //время жизни ответа в кэше
+	InfoCacheNegativeTTL time.Duration `env:"INFO_CACHE_NEGATIVE_TTL"` //время жизни ответа "песня не найдена" в кэше
+	InfoCachePersist     bool          `env:"INFO_CACHE_PERSIST"`      //хранить кэш в БД
+
+	InfoRateLimit   float64 `env:"INFO_RATE_LIMIT"`    //количество запросов к внешнему сервису в секунду (0 - без ограничения)
+	InfoRateBurst   int     `env:"INFO_RATE_BURST"`    //количество запросов к внешнему сервису, выполняемых сразу после простоя
+	InfoMaxInFlight int     `env:"INFO_MAX_IN_FLIGHT"` //количество одновременных запросов к внешнему сервису (0 - без ограничения)
+
+	LinkCheckWorkers      int           `env:"LINK_CHECK_WORKERS"`       //количество одновременных проверок ссылок (0 - проверка отключена)
+	LinkCheckInterval     time.Duration `env:"LINK_CHECK_INTERVAL"`      //период поиска ссылок для проверки
+	LinkCheckMaxAge       time.Duration `env:"LINK_CHECK_MAX_AGE"`       //через сколько ссылка проверяется повторно
+	LinkCheckHostDelay    time.Duration `env:"LINK_CHECK_HOST_DELAY"`    //минимальный интервал между запросами к одному сайту
+	LinkCheckTimeout      time.Duration `env:"LINK_CHECK_TIMEOUT"`       //таймаут проверки ссылки
+	LinkCheckMaxRedirects int           `env:"LINK_CHECK_MAX_REDIRECTS"` //максимальное количество перенаправлений
+	LinkCheckFailures     int           `env:"LINK_CHECK_FAILURES"`      //количество неудачных проверок подряд, после которого ссылка недоступна
+
+	Providers              []string `env:"PROVIDERS"`                 //источники данных о песнях в порядке приоритета: info, file, http
+	ProviderFile           string   `env:"PROVIDER_FILE"`             //файл с данными о песнях (.json или .csv) для источника file
+	ProviderHTTPURL        string   `env:"PROVIDER_HTTP_URL"`         //шаблон адреса источника http с подстановками {group} и {song}
+	ProviderHTTPMapping    string   `env:"PROVIDER_HTTP_MAPPING"`     //сопоставление полей источника http, например releaseDate=data.released,text=data.lyrics
+	ProviderHTTPDateLayout string   `env:"PROVIDER_HTTP_DATE_LAYOUT"` //формат даты источника http в терминах Go (необязательно)
 }
 
 func LoadConfig() (*Config, error) {
 	cfg := &Config{
-		ClientTimeout: clientTimeout,
+		ClientTimeout:    clientTimeout,
+		AutoMigrate:      true,
+		RetryMaxAttempts: retryMaxAttempts,
+		RetryBaseDelay:   retryBaseDelay,
+		RetryMaxDelay:    retryMaxDelay,
+		RetryJitter:      retryJitter,
+
+		BreakerFailureThreshold: breakerFailureThreshold,
+		BreakerOpenTimeout:      breakerOpenTimeout,
+		BreakerHalfOpenSuccess:  breakerHalfOpenSuccess,
+		EnrichWorkers:           enrichWorkers,
+		EnrichInterval:          enrichInterval,
+		EnrichMaxAttempts:       enrichMaxAttempts,
+		EnrichRetryDelay:        enrichRetryDelay,
+		EnrichMaxRetryDelay:     enrichMaxRetryDelay,
+
+		InfoCacheSize:        infoCacheSize,
+		InfoCacheTTL:         infoCacheTTL,
+		InfoCacheNegativeTTL: infoCacheNegativeTTL,
+
+		InfoRateLimit:   infoRateLimit,
+		InfoRateBurst:   infoRateBurst,
+		InfoMaxInFlight: infoMaxInFlight,
+
+		LinkCheckWorkers:      linkCheckWorkers,
+		LinkCheckInterval:     linkCheckInterval,
+		LinkCheckMaxAge:       linkCheckMaxAge,
+		LinkCheckHostDelay:    linkCheckHostDelay,
+		LinkCheckTimeout:      linkCheckTimeout,
+		LinkCheckMaxRedirects: linkCheckMaxRedirects,
+		LinkCheckFailures:     linkCheckFailures,
+
+		Providers: []string{"info"},
 	}
 
 	ex, err := os.Executable()
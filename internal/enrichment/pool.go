@@ -0,0 +1,177 @@
+package enrichment
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/lang"
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/rating"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// jobLease время, после которого задание в статусе running считается зависшим
+const jobLease = time.Minute * 5
+
+// Settings параметры пула обработчиков
+type Settings struct {
+	Workers       int           // количество обработчиков
+	PollInterval  time.Duration // период опроса пустой очереди
+	RetryDelay    time.Duration // задержка перед первым повтором задания
+	MaxRetryDelay time.Duration // максимальная задержка перед повтором
+}
+
+// Pool пул обработчиков очереди заданий на получение данных из внешнего сервиса
+type Pool struct {
+	stor     storage.Repository
+	info     infoservice.Client
+	breaker  *infoservice.BreakerClient
+	rater    *rating.Rater
+	log      logger.Logger
+	settings Settings
+}
+
+// NewPool создает пул обработчиков. Если задан предохранитель breaker,
+// задания не выполняются, пока он разомкнут
+func NewPool(stor storage.Repository, info infoservice.Client, breaker *infoservice.BreakerClient, rater *rating.Rater, log logger.Logger, settings Settings) *Pool {
+	if settings.Workers < 1 {
+		settings.Workers = 1
+	}
+	return &Pool{
+		stor:     stor,
+		info:     info,
+		breaker:  breaker,
+		rater:    rater,
+		log:      log,
+		settings: settings,
+	}
+}
+
+// Run запускает обработчики и ждет их завершения после отмены контекста
+func (p *Pool) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < p.settings.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(ctx)
+		}()
+	}
+
+	wg.Wait()
+}
+
+// work цикл одного обработчика
+func (p *Pool) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		if p.next(ctx) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.settings.PollInterval):
+		}
+	}
+}
+
+// next выполняет одно задание. Возвращает false, если заданий нет или выполнять их сейчас нельзя
+func (p *Pool) next(ctx context.Context) bool {
+	// пока предохранитель разомкнут, задания не забираем
+	if p.breaker != nil && p.breaker.State() == infoservice.StateOpen {
+		return false
+	}
+
+	job, err := p.stor.ClaimJob(ctx, jobLease)
+	if err != nil {
+		if !errors.Is(err, storage.ErrNoJobs) && ctx.Err() == nil {
+			p.log.Sugar.Infow("failed to claim job", "error", err)
+		}
+		return false
+	}
+
+	p.process(ctx, job)
+	return true
+}
+
+// process получает данные песни и сохраняет результат задания
+func (p *Pool) process(ctx context.Context, job model.Job) {
+	infoCtx := ctx
+	if job.Refresh {
+		infoCtx = infoservice.WithRefresh(ctx)
+	}
+
+	detail, err := p.info.GetSongDetail(infoCtx, job.Group, job.Song)
+	if err != nil {
+		p.fail(ctx, job, err)
+		return
+	}
+
+	song := enrichedSong(job.Group, job.Song, detail, p.rater)
+
+	if err := p.stor.CompleteJob(ctx, job, song); err != nil {
+		p.log.Sugar.Infow("failed to complete job", "job", job.ID, "error", err)
+		p.fail(ctx, job, err)
+		return
+	}
+
+	p.log.Sugar.Infow("song enriched", "job", job.ID, "group", job.Group, "song", job.Song)
+}
+
+// enrichedSong формирует песню по данным внешнего сервиса: определяет язык текста,
+// проверяет его на ненормативную лексику и запоминает хэш полученных данных
+func enrichedSong(group, name string, detail model.SongDetail, rater *rating.Rater) model.Song {
+	song := model.Song{Group: group, Song: name, SongDetail: detail}
+	song.Lang = lang.Detect(song.Text)
+	rater.RateSong(&song)
+	song.PayloadHash = detail.PayloadHash()
+	return song
+}
+
+// fail планирует повтор задания или переводит его в статус dead
+func (p *Pool) fail(ctx context.Context, job model.Job, cause error) {
+	// сервер останавливается: возвращаем задание в очередь без задержки
+	if ctx.Err() != nil {
+		if err := p.stor.RescheduleJob(context.WithoutCancel(ctx), job, 0, cause); err != nil {
+			p.log.Sugar.Infow("failed to return job to queue", "job", job.ID, "error", err)
+		}
+		return
+	}
+
+	// песни нет во внешнем сервисе или он отвечает некорректно - повтор не поможет
+	permanent := errors.Is(cause, infoservice.ErrNotFound) || errors.Is(cause, infoservice.ErrBadPayload)
+
+	if permanent || job.Attempts >= job.MaxAttempts {
+		p.log.Sugar.Infow("job failed permanently", "job", job.ID, "group", job.Group, "song", job.Song, "attempts", job.Attempts, "error", cause)
+		if err := p.stor.BuryJob(ctx, job, cause); err != nil {
+			p.log.Sugar.Infow("failed to bury job", "job", job.ID, "error", err)
+		}
+		return
+	}
+
+	delay := p.retryDelay(job.Attempts, cause)
+	p.log.Sugar.Debugw("job rescheduled", "job", job.ID, "attempts", job.Attempts, "delay", delay, "error", cause)
+	if err := p.stor.RescheduleJob(ctx, job, delay, cause); err != nil {
+		p.log.Sugar.Infow("failed to reschedule job", "job", job.ID, "error", err)
+	}
+}
+
+// retryDelay вычисляет экспоненциальную задержку перед повтором с учетом Retry-After
+func (p *Pool) retryDelay(attempt int, cause error) time.Duration {
+	d := p.settings.RetryDelay << (attempt - 1)
+	if d <= 0 || d > p.settings.MaxRetryDelay {
+		d = p.settings.MaxRetryDelay
+	}
+
+	var infoErr *infoservice.Error
+	if errors.As(cause, &infoErr) && infoErr.RetryAfter > d {
+		d = infoErr.RetryAfter
+	}
+
+	return d
+}
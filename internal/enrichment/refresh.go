@@ -0,0 +1,111 @@
+package enrichment
+
+import (
+	"context"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/providers"
+	"github.com/plasmatrip/muslib/internal/rating"
+	"github.com/plasmatrip/muslib/internal/storage"
+)
+
+// Refresher повторно получает данные песен из внешнего сервиса по запросу
+type Refresher struct {
+	stor  storage.Repository
+	info  infoservice.Client
+	rater *rating.Rater
+}
+
+// NewRefresher создает Refresher
+func NewRefresher(stor storage.Repository, info infoservice.Client, rater *rating.Rater) *Refresher {
+	return &Refresher{
+		stor:  stor,
+		info:  info,
+		rater: rater,
+	}
+}
+
+// Refresh запрашивает данные песни в обход кэша и сравнивает их с текущими.
+// Если apply равен false, изменения только возвращаются и не сохраняются
+func (r *Refresher) Refresh(ctx context.Context, current model.Song, apply bool) (model.EnrichResult, error) {
+	result := model.EnrichResult{
+		ID:    current.ID,
+		Group: current.Group,
+		Song:  current.Song,
+	}
+
+	detail, err := r.info.GetSongDetail(infoservice.WithRefresh(ctx), current.Group, current.Song)
+	if err != nil {
+		return result, err
+	}
+
+	fresh := replaceable(current, enrichedSong(current.Group, current.Song, detail, r.rater))
+
+	result.Changes = diff(current, fresh)
+	result.PayloadHash = fresh.PayloadHash
+	result.Stale = current.PayloadHash != fresh.PayloadHash
+
+	if !apply {
+		return result, nil
+	}
+
+	if err := r.stor.RefreshSong(ctx, current.ID, fresh); err != nil {
+		return result, err
+	}
+	result.Applied = true
+
+	return result, nil
+}
+
+// replaceable убирает из новых данных поля, которые источник не может заменить: заданные
+// вручную или полученные из источника с более высоким приоритетом (providers.SourceRanks).
+// Такие поля не попадают ни в изменения, ни в сохраняемые данные
+func replaceable(current, fresh model.Song) model.Song {
+	kept := func(field string) bool {
+		return !providers.Replaces(fresh.Sources[field], current.Sources[field])
+	}
+
+	sources := make(map[string]string, len(fresh.Sources))
+	for field, source := range fresh.Sources {
+		if !kept(field) {
+			sources[field] = source
+		}
+	}
+
+	if kept(providers.FieldReleaseDate) {
+		fresh.ReleaseDate = model.ReleaseDate{}
+	}
+	if kept(providers.FieldText) {
+		fresh.Text, fresh.Lang, fresh.Explicit, fresh.ExplicitTerms = "", "", false, nil
+	}
+	if kept(providers.FieldLink) {
+		fresh.Link = ""
+	}
+	fresh.Sources = sources
+
+	return fresh
+}
+
+// diff возвращает поля, которые изменятся при сохранении новых данных.
+// Пустые значения источника текущие данные не затирают, поэтому в изменения не попадают
+func diff(current, fresh model.Song) []model.FieldChange {
+	var changes []model.FieldChange
+
+	if !fresh.ReleaseDate.Time.IsZero() && (!fresh.ReleaseDate.Time.Equal(current.ReleaseDate.Time) ||
+		fresh.ReleaseDate.PrecisionOrDefault() != current.ReleaseDate.PrecisionOrDefault()) {
+		var old string
+		if !current.ReleaseDate.Time.IsZero() {
+			old = current.ReleaseDate.String()
+		}
+		changes = append(changes, model.FieldChange{Field: providers.FieldReleaseDate, Old: old, New: fresh.ReleaseDate.String()})
+	}
+	if fresh.Text != "" && fresh.Text != current.Text {
+		changes = append(changes, model.FieldChange{Field: providers.FieldText, Old: current.Text, New: fresh.Text})
+	}
+	if fresh.Link != "" && fresh.Link != current.Link {
+		changes = append(changes, model.FieldChange{Field: providers.FieldLink, Old: current.Link, New: fresh.Link})
+	}
+
+	return changes
+}
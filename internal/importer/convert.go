@@ -0,0 +1,69 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/lang"
+	"github.com/plasmatrip/muslib/internal/links"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/providers"
+	"github.com/plasmatrip/muslib/internal/rating"
+)
+
+// SourceImport источник полей, загруженных импортом
+const SourceImport = "import"
+
+// maxNameLength максимальная длина названия в БД
+const maxNameLength = 255
+
+// ToSong проверяет строку импорта и формирует из нее песню:
+// определяет язык текста и проверяет его на ненормативную лексику
+func (rec Record) ToSong(rater *rating.Rater) (model.Song, error) {
+	song := model.Song{Group: rec.Group, Song: rec.Song}
+	song.Normalize()
+
+	if song.Group == "" || song.Song == "" {
+		return song, errors.New("empty group name or song name")
+	}
+	if utf8.RuneCountInString(song.Group) > maxNameLength || utf8.RuneCountInString(song.Song) > maxNameLength {
+		return song, fmt.Errorf("group name and song name must not exceed %d characters", maxNameLength)
+	}
+	// ссылка проверяется так же, как при добавлении песни через API
+	if rec.Link != "" {
+		if _, err := links.Parse(rec.Link, ""); err != nil {
+			return song, err
+		}
+	}
+
+	if rec.ReleaseDate != "" {
+		rd, err := model.ParseReleaseDate(rec.ReleaseDate)
+		if err != nil {
+			return song, fmt.Errorf("invalid release date %q", rec.ReleaseDate)
+		}
+		song.ReleaseDate = rd
+	}
+	song.Text = rec.Text
+	song.Link = rec.Link
+
+	if err := infoservice.Validate(song.SongDetail); err != nil {
+		return song, err
+	}
+
+	song.Sources = make(map[string]string)
+	if !song.ReleaseDate.Time.IsZero() {
+		song.Sources[providers.FieldReleaseDate] = SourceImport
+	}
+	if song.Text != "" {
+		song.Sources[providers.FieldText] = SourceImport
+		song.Lang = lang.Detect(song.Text)
+		rater.RateSong(&song)
+	}
+	if song.Link != "" {
+		song.Sources[providers.FieldLink] = SourceImport
+	}
+
+	return song, nil
+}
@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Форматы импорта
+const (
+	FormatJSON   = "json"   // массив объектов
+	FormatNDJSON = "ndjson" // по одному объекту на строку
+	FormatCSV    = "csv"    // первая строка содержит названия колонок
+)
+
+// maxLineSize максимальный размер строки NDJSON
+const maxLineSize = 1 << 20
+
+// Record строка импорта в исходном виде
+type Record struct {
+	Group       string `json:"group"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// Reader последовательно читает строки импорта
+type Reader interface {
+	// Next возвращает номер и содержимое следующей строки. Ошибка разбора отдельной
+	// строки возвращается как *RowError, после нее чтение можно продолжить.
+	// По окончании данных возвращается io.EOF
+	Next() (int, Record, error)
+}
+
+// RowError ошибка разбора отдельной строки
+type RowError struct {
+	Line int
+	Err  error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// FormatFromContentType определяет формат по заголовку Content-Type
+func FormatFromContentType(contentType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q: %w", contentType, err)
+	}
+
+	switch mediaType {
+	case "application/json":
+		return FormatJSON, nil
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return FormatNDJSON, nil
+	case "text/csv", "application/csv":
+		return FormatCSV, nil
+	}
+	return "", fmt.Errorf("unsupported content type %q: application/json, application/x-ndjson or text/csv expected", mediaType)
+}
+
+// NewReader создает Reader для формата format
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatJSON:
+		return newJSONReader(r)
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &ndjsonReader{scanner: scanner}, nil
+	case FormatCSV:
+		return newCSVReader(r)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// jsonReader читает элементы JSON-массива по одному, не загружая массив целиком
+type jsonReader struct {
+	dec  *json.Decoder
+	line int
+}
+
+func newJSONReader(r io.Reader) (*jsonReader, error) {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON array: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("JSON array expected")
+	}
+
+	return &jsonReader{dec: dec}, nil
+}
+
+func (r *jsonReader) Next() (int, Record, error) {
+	if !r.dec.More() {
+		return 0, Record{}, io.EOF
+	}
+	r.line++
+
+	// элемент читается целиком, поэтому ошибка в его полях не мешает читать следующие
+	var raw json.RawMessage
+	if err := r.dec.Decode(&raw); err != nil {
+		return r.line, Record{}, fmt.Errorf("element %d: %w", r.line, err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return r.line, Record{}, &RowError{Line: r.line, Err: err}
+	}
+	return r.line, rec, nil
+}
+
+// ndjsonReader читает по одному JSON-объекту на строку, пустые строки пропускаются
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func (r *ndjsonReader) Next() (int, Record, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		data := strings.TrimSpace(r.scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal([]byte(data), &rec); err != nil {
+			return r.line, Record{}, &RowError{Line: r.line, Err: err}
+		}
+		return r.line, rec, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return r.line, Record{}, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+	return 0, Record{}, io.EOF
+}
+
+// csvReader читает CSV с заголовком. Колонки: group, song, releaseDate, text, link
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range []string{"group", "song"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Next() (int, Record, error) {
+	// ошибка разметки CSV сбивает разбор следующих строк, поэтому чтение прекращается
+	record, err := r.reader.Read()
+	if err != nil {
+		return 0, Record{}, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	value := func(name string) string {
+		if i, ok := r.columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	return line, Record{
+		Group:       value("group"),
+		Song:        value("song"),
+		ReleaseDate: value("releaseDate"),
+		Text:        value("text"),
+		Link:        value("link"),
+	}, nil
+}
@@ -0,0 +1,158 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// ErrCircuitOpen возвращается без обращения к сервису, пока предохранитель разомкнут
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState состояние предохранителя
+type BreakerState int
+
+const (
+	StateClosed   BreakerState = iota // запросы проходят
+	StateOpen                         // запросы отклоняются
+	StateHalfOpen                     // пропускаются пробные запросы
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerSettings параметры предохранителя
+type BreakerSettings struct {
+	FailureThreshold int           // количество ошибок подряд, после которого предохранитель размыкается
+	OpenTimeout      time.Duration // время в разомкнутом состоянии до пробных запросов
+	HalfOpenSuccess  int           // количество успешных пробных запросов для замыкания
+}
+
+// BreakerClient предохранитель (circuit breaker) для внешнего сервиса
+type BreakerClient struct {
+	next     Client
+	settings BreakerSettings
+
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	successes int
+	probing   bool
+	openedAt  time.Time
+}
+
+// NewBreakerClient оборачивает клиента next предохранителем
+func NewBreakerClient(next Client, settings BreakerSettings) *BreakerClient {
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = 1
+	}
+	if settings.HalfOpenSuccess < 1 {
+		settings.HalfOpenSuccess = 1
+	}
+	return &BreakerClient{next: next, settings: settings}
+}
+
+// State возвращает текущее состояние предохранителя
+func (b *BreakerClient) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	return b.state
+}
+
+// GetSongDetail выполняет запрос, если предохранитель его пропускает
+func (b *BreakerClient) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	if !b.allow() {
+		return model.SongDetail{}, &Error{Kind: ErrUnavailable, Err: ErrCircuitOpen}
+	}
+
+	detail, err := b.next.GetSongDetail(ctx, group, song)
+
+	// отмена запроса клиентом не говорит о состоянии сервиса
+	if err != nil && ctx.Err() != nil {
+		b.release()
+		return detail, err
+	}
+
+	b.record(err == nil || !Retryable(err))
+	return detail, err
+}
+
+// allow решает, можно ли выполнить запрос
+func (b *BreakerClient) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+
+	switch b.state {
+	case StateOpen:
+		return false
+	case StateHalfOpen:
+		// в полуоткрытом состоянии одновременно выполняется только один пробный запрос
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// release снимает отметку пробного запроса без учета результата
+func (b *BreakerClient) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// record учитывает результат запроса
+func (b *BreakerClient) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if success {
+		b.failures = 0
+		if b.state == StateHalfOpen {
+			b.successes++
+			if b.successes >= b.settings.HalfOpenSuccess {
+				b.state = StateClosed
+				b.successes = 0
+			}
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.settings.FailureThreshold {
+		b.state = StateOpen
+		b.openedAt = time.Now()
+		b.failures = 0
+		b.successes = 0
+	}
+}
+
+// refresh переводит предохранитель в полуоткрытое состояние по истечении таймаута.
+// Вызывается под блокировкой
+func (b *BreakerClient) refresh() {
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.settings.OpenTimeout {
+		b.state = StateHalfOpen
+		b.successes = 0
+		b.probing = false
+	}
+}
@@ -0,0 +1,144 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// blockingClient ждет отмены контекста и возвращает ошибку, как клиент при таймауте
+type blockingClient struct {
+	started chan struct{}
+}
+
+func (c *blockingClient) GetSongDetail(ctx context.Context, _, _ string) (model.SongDetail, error) {
+	c.started <- struct{}{}
+	<-ctx.Done()
+	return model.SongDetail{}, &Error{Kind: ErrUnavailable, Err: ctx.Err()}
+}
+
+func TestBreakerClientTransitions(t *testing.T) {
+	ctx := context.Background()
+	fail := unavailable(http.StatusServiceUnavailable, 0)
+
+	next := &stubClient{errs: []error{fail, fail}}
+	b := NewBreakerClient(next, BreakerSettings{FailureThreshold: 2, OpenTimeout: 20 * time.Millisecond, HalfOpenSuccess: 2})
+
+	// первая ошибка не размыкает предохранитель
+	b.GetSongDetail(ctx, "Muse", "Uprising")
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want %s", b.State(), StateClosed)
+	}
+
+	b.GetSongDetail(ctx, "Muse", "Uprising")
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want %s", b.State(), StateOpen)
+	}
+
+	// разомкнутый предохранитель отклоняет запросы, не обращаясь к сервису
+	_, err := b.GetSongDetail(ctx, "Muse", "Uprising")
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want %v", err, ErrCircuitOpen)
+	}
+	if next.Calls() != 2 {
+		t.Fatalf("calls = %d, want 2", next.Calls())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s, want %s", b.State(), StateHalfOpen)
+	}
+
+	// для замыкания нужны два успешных пробных запроса
+	if _, err := b.GetSongDetail(ctx, "Muse", "Uprising"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s, want %s", b.State(), StateHalfOpen)
+	}
+	if _, err := b.GetSongDetail(ctx, "Muse", "Uprising"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want %s", b.State(), StateClosed)
+	}
+}
+
+func TestBreakerClientHalfOpenFailure(t *testing.T) {
+	ctx := context.Background()
+	fail := unavailable(0, 0)
+
+	next := &stubClient{errs: []error{fail, fail}}
+	b := NewBreakerClient(next, BreakerSettings{FailureThreshold: 1, OpenTimeout: 20 * time.Millisecond})
+
+	b.GetSongDetail(ctx, "Muse", "Uprising")
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want %s", b.State(), StateOpen)
+	}
+
+	// неудачный пробный запрос снова размыкает предохранитель
+	time.Sleep(30 * time.Millisecond)
+	b.GetSongDetail(ctx, "Muse", "Uprising")
+	if b.State() != StateOpen {
+		t.Fatalf("state = %s, want %s", b.State(), StateOpen)
+	}
+}
+
+func TestBreakerClientIgnoresPermanentErrors(t *testing.T) {
+	ctx := context.Background()
+
+	next := &stubClient{errs: []error{
+		&Error{Kind: ErrNotFound, StatusCode: http.StatusNotFound},
+		&Error{Kind: ErrBadPayload, StatusCode: http.StatusOK},
+		unavailable(http.StatusForbidden, 0),
+	}}
+	b := NewBreakerClient(next, BreakerSettings{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	// ответы сервиса, которые нет смысла повторять, не говорят о его недоступности
+	for range 3 {
+		b.GetSongDetail(ctx, "Muse", "Uprising")
+	}
+	if b.State() != StateClosed {
+		t.Fatalf("state = %s, want %s", b.State(), StateClosed)
+	}
+}
+
+func TestBreakerClientReleaseOnCancel(t *testing.T) {
+	block := &blockingClient{started: make(chan struct{}, 1)}
+	b := NewBreakerClient(block, BreakerSettings{FailureThreshold: 1, OpenTimeout: 10 * time.Millisecond})
+
+	// переводим предохранитель в полуоткрытое состояние
+	b.record(false)
+	time.Sleep(20 * time.Millisecond)
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s, want %s", b.State(), StateHalfOpen)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.GetSongDetail(ctx, "Muse", "Uprising")
+	}()
+	<-block.started
+
+	// пока выполняется пробный запрос, остальные отклоняются
+	if _, err := b.GetSongDetail(context.Background(), "Muse", "Uprising"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want %v", err, ErrCircuitOpen)
+	}
+
+	cancel()
+	<-done
+
+	// отмена пробного запроса не размыкает предохранитель и освобождает место для следующей пробы
+	if b.State() != StateHalfOpen {
+		t.Fatalf("state = %s, want %s", b.State(), StateHalfOpen)
+	}
+	if !b.allow() {
+		t.Fatal("probe is not released after cancel")
+	}
+}
@@ -0,0 +1,205 @@
+package infoservice
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// CacheStore постоянное хранилище кэша, например таблица в БД
+type CacheStore interface {
+	GetCachedDetail(ctx context.Context, key string) (model.CachedDetail, bool, error)
+	PutCachedDetail(ctx context.Context, key string, entry model.CachedDetail) error
+}
+
+// CacheSettings параметры кэша
+type CacheSettings struct {
+	Size        int           // максимальное количество записей в памяти
+	TTL         time.Duration // время жизни найденных записей
+	NegativeTTL time.Duration // время жизни записей о ненайденных песнях
+}
+
+// CacheStats статистика кэша
+type CacheStats struct {
+	Size         int    `json:"size"`
+	Hits         uint64 `json:"hits"`
+	NegativeHits uint64 `json:"negative_hits"`
+	StoreHits    uint64 `json:"store_hits"`
+	Misses       uint64 `json:"misses"`
+	Evictions    uint64 `json:"evictions"`
+	StoreErrors  uint64 `json:"store_errors"`
+}
+
+type cacheItem struct {
+	key   string
+	entry model.CachedDetail
+}
+
+// CacheClient кэширует ответы внешнего сервиса: в памяти (LRU с TTL)
+// и, если задано хранилище, в БД. Ответы "не найдено" тоже кэшируются
+type CacheClient struct {
+	next     Client
+	store    CacheStore
+	settings CacheSettings
+
+	mu    sync.Mutex
+	items map[string]*list.Element
+	order *list.List
+
+	hits, negativeHits, storeHits, misses, evictions, storeErrors atomic.Uint64
+}
+
+// NewCacheClient оборачивает клиента next кэшем. store может быть nil
+func NewCacheClient(next Client, store CacheStore, settings CacheSettings) *CacheClient {
+	return &CacheClient{
+		next:     next,
+		store:    store,
+		settings: settings,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// CacheKey возвращает ключ кэша для нормализованных названий группы и песни
+func CacheKey(group, song string) string {
+	return strings.ToLower(model.NormalizeName(group)) + "\x1f" + strings.ToLower(model.NormalizeName(song))
+}
+
+// GetSongDetail возвращает ответ из кэша или запрашивает его у сервиса
+func (c *CacheClient) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	key := CacheKey(group, song)
+	refresh := isRefresh(ctx)
+
+	if entry, ok := c.get(key); ok && !refresh {
+		return c.answer(entry, &c.hits)
+	}
+
+	if c.store != nil && !refresh {
+		entry, ok, err := c.store.GetCachedDetail(ctx, key)
+		if err != nil {
+			c.storeErrors.Add(1)
+		}
+		if ok && time.Now().Before(entry.ExpiresAt) {
+			c.put(key, entry)
+			return c.answer(entry, &c.storeHits)
+		}
+	}
+
+	c.misses.Add(1)
+
+	detail, err := c.next.GetSongDetail(ctx, group, song)
+
+	var entry model.CachedDetail
+	switch {
+	case err == nil:
+		entry = model.CachedDetail{Detail: detail, ExpiresAt: time.Now().Add(c.settings.TTL)}
+	case errors.Is(err, ErrNotFound):
+		entry = model.CachedDetail{NotFound: true, ExpiresAt: time.Now().Add(c.settings.NegativeTTL)}
+	default:
+		// временные ошибки не кэшируем
+		return detail, err
+	}
+
+	c.put(key, entry)
+	if c.store != nil {
+		if err := c.store.PutCachedDetail(ctx, key, entry); err != nil {
+			c.storeErrors.Add(1)
+		}
+	}
+
+	return detail, err
+}
+
+// refreshKey ключ контекста для запросов в обход кэша
+type refreshKey struct{}
+
+// WithRefresh возвращает контекст, запросы с которым не читают ответ из кэша:
+// ответ всегда запрашивается у сервиса и сохраняется в кэш
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// isRefresh проверяет, нужно ли запросить ответ в обход кэша
+func isRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+	return refresh
+}
+
+// Stats возвращает статистику кэша
+func (c *CacheClient) Stats() CacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Size:         size,
+		Hits:         c.hits.Load(),
+		NegativeHits: c.negativeHits.Load(),
+		StoreHits:    c.storeHits.Load(),
+		Misses:       c.misses.Load(),
+		Evictions:    c.evictions.Load(),
+		StoreErrors:  c.storeErrors.Load(),
+	}
+}
+
+// answer формирует ответ по записи кэша и учитывает попадание
+func (c *CacheClient) answer(entry model.CachedDetail, counter *atomic.Uint64) (model.SongDetail, error) {
+	if entry.NotFound {
+		c.negativeHits.Add(1)
+		return model.SongDetail{}, &Error{Kind: ErrNotFound}
+	}
+	counter.Add(1)
+	return entry.Detail, nil
+}
+
+// get ищет действующую запись в памяти
+func (c *CacheClient) get(key string) (model.CachedDetail, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return model.CachedDetail{}, false
+	}
+
+	item := el.Value.(*cacheItem)
+	if time.Now().After(item.entry.ExpiresAt) {
+		c.order.Remove(el)
+		delete(c.items, key)
+		return model.CachedDetail{}, false
+	}
+
+	c.order.MoveToFront(el)
+	return item.entry, true
+}
+
+// put сохраняет запись в памяти, вытесняя самые старые по использованию
+func (c *CacheClient) put(key string, entry model.CachedDetail) {
+	if c.settings.Size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheItem).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cacheItem{key: key, entry: entry})
+
+	for c.order.Len() > c.settings.Size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheItem).key)
+		c.evictions.Add(1)
+	}
+}
@@ -0,0 +1,203 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// memoryStore хранилище кэша в памяти
+type memoryStore struct {
+	mu      sync.Mutex
+	entries map[string]model.CachedDetail
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{entries: map[string]model.CachedDetail{}}
+}
+
+func (s *memoryStore) GetCachedDetail(_ context.Context, key string) (model.CachedDetail, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	return entry, ok, nil
+}
+
+func (s *memoryStore) PutCachedDetail(_ context.Context, key string, entry model.CachedDetail) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = entry
+	return nil
+}
+
+func TestCacheClientHit(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.Set("Muse", "Uprising", model.SongDetail{Text: "Paranoia is in bloom"})
+
+	c := NewCacheClient(fake, nil, CacheSettings{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+
+	for range 3 {
+		detail, err := c.GetSongDetail(ctx, "Muse", "Uprising")
+		if err != nil || detail.Text != "Paranoia is in bloom" {
+			t.Fatalf("detail = %+v, err = %v", detail, err)
+		}
+	}
+	// ключ не зависит от регистра и лишних пробелов
+	if _, err := c.GetSongDetail(ctx, " muse ", "UPRISING"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if fake.Calls() != 1 {
+		t.Errorf("calls = %d, want 1", fake.Calls())
+	}
+	if stats := c.Stats(); stats.Hits != 3 || stats.Misses != 1 {
+		t.Errorf("stats = %+v, want 3 hits and 1 miss", stats)
+	}
+}
+
+func TestCacheClientNegativeTTL(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+
+	c := NewCacheClient(fake, nil, CacheSettings{Size: 10, TTL: time.Minute, NegativeTTL: 20 * time.Millisecond})
+
+	for range 2 {
+		if _, err := c.GetSongDetail(ctx, "Muse", "Uprising"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("err = %v, want %v", err, ErrNotFound)
+		}
+	}
+	if fake.Calls() != 1 {
+		t.Fatalf("calls = %d, want 1", fake.Calls())
+	}
+	if stats := c.Stats(); stats.NegativeHits != 1 {
+		t.Errorf("negative hits = %d, want 1", stats.NegativeHits)
+	}
+
+	// после истечения NegativeTTL песня запрашивается снова
+	fake.Set("Muse", "Uprising", model.SongDetail{Text: "Paranoia is in bloom"})
+	time.Sleep(30 * time.Millisecond)
+
+	detail, err := c.GetSongDetail(ctx, "Muse", "Uprising")
+	if err != nil || detail.Text != "Paranoia is in bloom" {
+		t.Fatalf("detail = %+v, err = %v", detail, err)
+	}
+	if fake.Calls() != 2 {
+		t.Errorf("calls = %d, want 2", fake.Calls())
+	}
+}
+
+func TestCacheClientSkipsTemporaryErrors(t *testing.T) {
+	ctx := context.Background()
+	next := &stubClient{errs: []error{unavailable(503, 0)}}
+
+	c := NewCacheClient(next, nil, CacheSettings{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+
+	if _, err := c.GetSongDetail(ctx, "Muse", "Uprising"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want %v", err, ErrUnavailable)
+	}
+	if _, err := c.GetSongDetail(ctx, "Muse", "Uprising"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if next.Calls() != 2 {
+		t.Errorf("calls = %d, want 2", next.Calls())
+	}
+}
+
+func TestCacheClientRefresh(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	fake.Set("Muse", "Uprising", model.SongDetail{Text: "old"})
+
+	store := newMemoryStore()
+	c := NewCacheClient(fake, store, CacheSettings{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+
+	if _, err := c.GetSongDetail(ctx, "Muse", "Uprising"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// запрос в обход кэша обращается к сервису и обновляет обе записи кэша
+	fake.Set("Muse", "Uprising", model.SongDetail{Text: "new"})
+	detail, err := c.GetSongDetail(WithRefresh(ctx), "Muse", "Uprising")
+	if err != nil || detail.Text != "new" {
+		t.Fatalf("detail = %+v, err = %v", detail, err)
+	}
+	if fake.Calls() != 2 {
+		t.Fatalf("calls = %d, want 2", fake.Calls())
+	}
+
+	detail, _ = c.GetSongDetail(ctx, "Muse", "Uprising")
+	if detail.Text != "new" {
+		t.Errorf("cached detail = %+v, want refreshed", detail)
+	}
+	if entry, _, _ := store.GetCachedDetail(ctx, CacheKey("Muse", "Uprising")); entry.Detail.Text != "new" {
+		t.Errorf("stored detail = %+v, want refreshed", entry.Detail)
+	}
+	if fake.Calls() != 2 {
+		t.Errorf("calls = %d, want 2", fake.Calls())
+	}
+}
+
+func TestCacheClientStore(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	store := newMemoryStore()
+	store.PutCachedDetail(ctx, CacheKey("Muse", "Uprising"), model.CachedDetail{
+		Detail:    model.SongDetail{Text: "stored"},
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	store.PutCachedDetail(ctx, CacheKey("Muse", "Starlight"), model.CachedDetail{
+		Detail:    model.SongDetail{Text: "expired"},
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	fake.Set("Muse", "Starlight", model.SongDetail{Text: "fresh"})
+
+	c := NewCacheClient(fake, store, CacheSettings{Size: 10, TTL: time.Minute, NegativeTTL: time.Minute})
+
+	if detail, _ := c.GetSongDetail(ctx, "Muse", "Uprising"); detail.Text != "stored" {
+		t.Errorf("detail = %+v, want stored", detail)
+	}
+	// устаревшая запись хранилища не используется
+	if detail, _ := c.GetSongDetail(ctx, "Muse", "Starlight"); detail.Text != "fresh" {
+		t.Errorf("detail = %+v, want fresh", detail)
+	}
+	if fake.Calls() != 1 {
+		t.Errorf("calls = %d, want 1", fake.Calls())
+	}
+	if stats := c.Stats(); stats.StoreHits != 1 {
+		t.Errorf("store hits = %d, want 1", stats.StoreHits)
+	}
+}
+
+func TestCacheClientEviction(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake()
+	for _, song := range []string{"Uprising", "Starlight", "Hysteria"} {
+		fake.Set("Muse", song, model.SongDetail{Text: song})
+	}
+
+	c := NewCacheClient(fake, nil, CacheSettings{Size: 2, TTL: time.Minute, NegativeTTL: time.Minute})
+
+	c.GetSongDetail(ctx, "Muse", "Uprising")
+	c.GetSongDetail(ctx, "Muse", "Starlight")
+	// обращение делает запись последней использованной, вытесняется Starlight
+	c.GetSongDetail(ctx, "Muse", "Uprising")
+	c.GetSongDetail(ctx, "Muse", "Hysteria")
+
+	calls := fake.Calls()
+	c.GetSongDetail(ctx, "Muse", "Uprising")
+	if fake.Calls() != calls {
+		t.Errorf("recently used entry was evicted")
+	}
+	c.GetSongDetail(ctx, "Muse", "Starlight")
+	if fake.Calls() != calls+1 {
+		t.Errorf("least recently used entry was not evicted")
+	}
+	if stats := c.Stats(); stats.Size != 2 || stats.Evictions != 2 {
+		t.Errorf("stats = %+v, want size 2 and 2 evictions", stats)
+	}
+}
@@ -0,0 +1,120 @@
+package infoservice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// maxBodySize ограничение размера ответа внешнего сервиса
+const maxBodySize = 1 << 20
+
+// Client получает расширенную информацию о песне из внешнего сервиса
+type Client interface {
+	GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error)
+}
+
+// HTTPClient клиент внешнего сервиса, работающий по HTTP
+type HTTPClient struct {
+	addr   string
+	client *http.Client
+}
+
+// NewClient создает клиента внешнего сервиса с адресом addr
+func NewClient(addr string, timeout time.Duration) *HTTPClient {
+	return &HTTPClient{
+		addr:   addr,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// GetSongDetail запрашивает GET {addr}/info?group=&song=
+func (c *HTTPClient) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	var detail model.SongDetail
+
+	params := url.Values{}
+	params.Add("group", group)
+	params.Add("song", song)
+
+	fullURL := fmt.Sprintf("%s/info?%s", c.addr, params.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return detail, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return detail, &Error{Kind: ErrUnavailable, Err: err}
+	}
+	defer resp.Body.Close()
+
+	// Проверяем код ответа
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotFound:
+		return detail, &Error{Kind: ErrNotFound, StatusCode: resp.StatusCode}
+	case resp.StatusCode == http.StatusBadRequest:
+		return detail, &Error{Kind: ErrBadPayload, StatusCode: resp.StatusCode, Err: errors.New("request rejected")}
+	default:
+		return detail, &Error{
+			Kind:       ErrUnavailable,
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	// Декодируем ответ
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&detail); err != nil {
+		return model.SongDetail{}, &Error{Kind: ErrBadPayload, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if err := Validate(detail); err != nil {
+		return model.SongDetail{}, &Error{Kind: ErrBadPayload, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	return detail, nil
+}
+
+// Validate проверяет данные, полученные от внешнего сервиса
+func Validate(detail model.SongDetail) error {
+	if detail.ReleaseDate.Time.After(time.Now()) {
+		return fmt.Errorf("release date %s is in the future", detail.ReleaseDate)
+	}
+
+	if detail.Link != "" {
+		u, err := url.Parse(detail.Link)
+		if err != nil {
+			return fmt.Errorf("invalid link: %w", err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid link %q: absolute http(s) URL expected", detail.Link)
+		}
+	}
+
+	return nil
+}
+
+// parseRetryAfter разбирает заголовок Retry-After в секундах или в формате HTTP-даты
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
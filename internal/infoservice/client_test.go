@@ -0,0 +1,125 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+func TestHTTPClientGetSongDetail(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		header     map[string]string
+		body       string
+		wantKind   error
+		wantRetry  time.Duration
+		wantDetail model.SongDetail
+	}{
+		{
+			name:       "ok",
+			status:     http.StatusOK,
+			body:       `{"releaseDate":"2006-07-16","text":"Ooh baby","link":"https://example.com/song"}`,
+			wantDetail: model.SongDetail{ReleaseDate: model.ReleaseDate{Time: time.Date(2006, 7, 16, 0, 0, 0, 0, time.UTC), Precision: model.PrecisionDay}, Text: "Ooh baby", Link: "https://example.com/song"},
+		},
+		{name: "not found", status: http.StatusNotFound, wantKind: ErrNotFound},
+		{name: "bad request", status: http.StatusBadRequest, wantKind: ErrBadPayload},
+		{name: "server error", status: http.StatusBadGateway, wantKind: ErrUnavailable},
+		{
+			name:      "too many requests",
+			status:    http.StatusTooManyRequests,
+			header:    map[string]string{"Retry-After": "7"},
+			wantKind:  ErrUnavailable,
+			wantRetry: 7 * time.Second,
+		},
+		{name: "broken json", status: http.StatusOK, body: `{"releaseDate":`, wantKind: ErrBadPayload},
+		{name: "relative link", status: http.StatusOK, body: `{"link":"/song"}`, wantKind: ErrBadPayload},
+		{name: "unknown date format", status: http.StatusOK, body: `{"releaseDate":"yesterday"}`, wantKind: ErrBadPayload},
+		{name: "future date", status: http.StatusOK, body: `{"releaseDate":"2999"}`, wantKind: ErrBadPayload},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/info" || r.URL.Query().Get("group") != "Muse" || r.URL.Query().Get("song") != "Supermassive Black Hole" {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			detail, err := NewClient(srv.URL, time.Second).GetSongDetail(context.Background(), "Muse", "Supermassive Black Hole")
+
+			if tt.wantKind == nil {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if detail.Text != tt.wantDetail.Text || detail.Link != tt.wantDetail.Link ||
+					!detail.ReleaseDate.Time.Equal(tt.wantDetail.ReleaseDate.Time) || detail.ReleaseDate.Precision != tt.wantDetail.ReleaseDate.Precision {
+					t.Errorf("detail = %+v, want %+v", detail, tt.wantDetail)
+				}
+				return
+			}
+
+			if !errors.Is(err, tt.wantKind) {
+				t.Fatalf("err = %v, want %v", err, tt.wantKind)
+			}
+			var infoErr *Error
+			if !errors.As(err, &infoErr) {
+				t.Fatalf("err = %T, want *Error", err)
+			}
+			if infoErr.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", infoErr.StatusCode, tt.status)
+			}
+			if infoErr.RetryAfter != tt.wantRetry {
+				t.Errorf("retry after = %s, want %s", infoErr.RetryAfter, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestHTTPClientUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.URL
+	srv.Close()
+
+	_, err := NewClient(addr, time.Second).GetSongDetail(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrUnavailable) || !Retryable(err) {
+		t.Fatalf("err = %v, want retryable %v", err, ErrUnavailable)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "empty", value: "", min: 0, max: 0},
+		{name: "seconds", value: "30", min: 30 * time.Second, max: 30 * time.Second},
+		{name: "zero seconds", value: "0", min: 0, max: 0},
+		{name: "negative seconds", value: "-5", min: 0, max: 0},
+		{name: "garbage", value: "soon", min: 0, max: 0},
+		{name: "http date", value: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 55 * time.Second, max: time.Minute},
+		{name: "past http date", value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), min: 0, max: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %s, want between %s and %s", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
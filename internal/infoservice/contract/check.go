@@ -0,0 +1,162 @@
+package contract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+)
+
+// maxBodySize ограничение размера проверяемого ответа
+const maxBodySize = 1 << 20
+
+// Case песня, на которой проверяется взаимодействие с внешним сервисом
+type Case struct {
+	Group string
+	Song  string
+}
+
+// Result результат проверки одного запроса
+type Result struct {
+	Case
+	Status     int     // код ответа внешнего сервиса
+	Violations []error // нарушения контракта в запросе клиента и в ответе сервиса
+	ClientErr  error   // ошибка, которую вернул клиент muslib
+}
+
+// OK возвращает true, если нарушений контракта нет
+func (r Result) OK() bool {
+	return len(r.Violations) == 0
+}
+
+// Check проверяет взаимодействие клиента muslib с внешним сервисом по адресу addr.
+// Клиент обращается к сервису через проверяющий прокси: запросы клиента и ответы
+// сервиса сверяются с контрактом. Дополнительно проверяется, что клиент принимает
+// все ответы, которые соответствуют контракту
+func Check(ctx context.Context, c *Contract, addr string, timeout time.Duration, cases []Case) ([]Result, error) {
+	p := &proxy{
+		contract: c,
+		upstream: strings.TrimSuffix(addr, "/"),
+		client:   &http.Client{Timeout: timeout},
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to start contract proxy: %w", err)
+	}
+	server := http.Server{Handler: p}
+	go server.Serve(listener)
+	defer server.Shutdown(context.WithoutCancel(ctx))
+
+	client := infoservice.NewClient("http://"+listener.Addr().String(), timeout)
+
+	results := make([]Result, 0, len(cases))
+	for _, tc := range cases {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		p.reset()
+		_, clientErr := client.GetSongDetail(ctx, tc.Group, tc.Song)
+		exchange := p.last()
+
+		result := Result{Case: tc, Status: exchange.status, Violations: exchange.violations, ClientErr: clientErr}
+		if exchange.err != nil {
+			result.Violations = append(result.Violations, exchange.err)
+		}
+
+		// ответ соответствует контракту, но клиент его не принял
+		if exchange.status == http.StatusOK && result.OK() && errors.Is(clientErr, infoservice.ErrBadPayload) {
+			result.Violations = append(result.Violations, fmt.Errorf("client rejected a response valid under contract: %w", clientErr))
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// exchange запрос клиента и ответ сервиса, прошедшие через прокси
+type exchange struct {
+	status     int
+	violations []error
+	err        error
+}
+
+// proxy передает запросы клиента во внешний сервис, проверяя их и ответы по контракту
+type proxy struct {
+	contract *Contract
+	upstream string
+	client   *http.Client
+
+	mu       sync.Mutex
+	exchange exchange
+}
+
+func (p *proxy) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.exchange = exchange{}
+}
+
+func (p *proxy) last() exchange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.exchange
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var ex exchange
+	defer func() {
+		p.mu.Lock()
+		p.exchange = ex
+		p.mu.Unlock()
+	}()
+
+	if err := p.contract.ValidateRequest(r); err != nil {
+		ex.violations = append(ex.violations, fmt.Errorf("request: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, p.upstream+r.URL.RequestURI(), nil)
+	if err != nil {
+		ex.err = err
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		ex.err = fmt.Errorf("info service is unreachable: %w", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		ex.err = fmt.Errorf("failed to read response: %w", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	ex.status = resp.StatusCode
+	if err := p.contract.ValidateResponse(r.Method, r.URL.Path, resp.StatusCode, resp.Header.Get("Content-Type"), body); err != nil {
+		ex.violations = append(ex.violations, fmt.Errorf("response: %w", err))
+	}
+
+	for _, h := range []string{"Content-Type", "Retry-After"} {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, bytes.NewReader(body))
+}
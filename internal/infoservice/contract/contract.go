@@ -0,0 +1,324 @@
+package contract
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// spec контракт внешнего сервиса в формате OpenAPI 3.0
+//
+//go:embed openapi.json
+var spec []byte
+
+// Contract разобранное описание API внешнего сервиса.
+// Поддерживается подмножество OpenAPI, достаточное для проверки GET /info
+type Contract struct {
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components struct {
+		Schemas map[string]schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type operation struct {
+	Parameters []parameter         `json:"parameters"`
+	Responses  map[string]response `json:"responses"`
+}
+
+type parameter struct {
+	Name     string `json:"name"`
+	In       string `json:"in"`
+	Required bool   `json:"required"`
+	Schema   schema `json:"schema"`
+}
+
+type response struct {
+	Content map[string]struct {
+		Schema schema `json:"schema"`
+	} `json:"content"`
+}
+
+type schema struct {
+	Ref                  string            `json:"$ref"`
+	Type                 string            `json:"type"`
+	Format               string            `json:"format"`
+	Pattern              string            `json:"pattern"`
+	Required             []string          `json:"required"`
+	Properties           map[string]schema `json:"properties"`
+	AdditionalProperties *bool             `json:"additionalProperties"`
+	Items                *schema           `json:"items"`
+}
+
+// Load возвращает встроенный контракт внешнего сервиса
+func Load() (*Contract, error) {
+	return Parse(spec)
+}
+
+// Parse разбирает контракт в формате OpenAPI (JSON)
+func Parse(data []byte) (*Contract, error) {
+	var c Contract
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse contract: %w", err)
+	}
+	if len(c.Paths) == 0 {
+		return nil, errors.New("contract has no paths")
+	}
+	return &c, nil
+}
+
+// Spec возвращает исходный текст встроенного контракта
+func Spec() []byte {
+	return spec
+}
+
+// operation находит описание метода по пути запроса
+func (c *Contract) operation(method, path string) (operation, error) {
+	item, ok := c.Paths[path]
+	if !ok {
+		return operation{}, fmt.Errorf("path %s is not described in contract", path)
+	}
+	op, ok := item[strings.ToLower(method)]
+	if !ok {
+		return operation{}, fmt.Errorf("method %s %s is not described in contract", method, path)
+	}
+	return op, nil
+}
+
+// ValidateRequest проверяет, что запрос к внешнему сервису соответствует контракту:
+// метод и путь описаны, обязательные параметры переданы, лишних параметров нет
+func (c *Contract) ValidateRequest(r *http.Request) error {
+	op, err := c.operation(r.Method, r.URL.Path)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	query := r.URL.Query()
+	known := make(map[string]bool)
+
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			continue
+		}
+		known[p.Name] = true
+
+		values, ok := query[p.Name]
+		if !ok || len(values) == 0 || values[0] == "" {
+			if p.Required {
+				errs = append(errs, fmt.Errorf("query parameter %q is required", p.Name))
+			}
+			continue
+		}
+		if len(values) > 1 {
+			errs = append(errs, fmt.Errorf("query parameter %q is passed %d times", p.Name, len(values)))
+		}
+		if err := c.validateParam(p, values[0]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for name := range query {
+		if !known[name] {
+			errs = append(errs, fmt.Errorf("query parameter %q is not described in contract", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateParam проверяет значение параметра запроса по его схеме
+func (c *Contract) validateParam(p parameter, value string) error {
+	var v any = value
+	switch p.Schema.Type {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("query parameter %q: number expected, got %q", p.Name, value)
+		}
+		v = n
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("query parameter %q: boolean expected, got %q", p.Name, value)
+		}
+		v = b
+	}
+	return errors.Join(c.validate("query."+p.Name, v, p.Schema)...)
+}
+
+// ValidateResponse проверяет ответ внешнего сервиса: код ответа описан в контракте,
+// а тело, если для кода задана схема, ей соответствует
+func (c *Contract) ValidateResponse(method, path string, status int, contentType string, body []byte) error {
+	op, err := c.operation(method, path)
+	if err != nil {
+		return err
+	}
+
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		if resp, ok = op.Responses["default"]; !ok {
+			return fmt.Errorf("status %d is not described in contract", status)
+		}
+	}
+	if len(resp.Content) == 0 {
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	content, ok := resp.Content[mediaType]
+	if !ok {
+		return fmt.Errorf("status %d: content type %q is not described in contract", status, contentType)
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("status %d: invalid JSON body: %w", status, err)
+	}
+
+	return errors.Join(c.validate("body", v, content.Schema)...)
+}
+
+// validate проверяет значение v по схеме s и возвращает все найденные нарушения
+func (c *Contract) validate(path string, v any, s schema) []error {
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		ref, exist := c.Components.Schemas[name]
+		if !ok || !exist {
+			return []error{fmt.Errorf("%s: unresolved schema reference %q", path, s.Ref)}
+		}
+		s = ref
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []error{fmt.Errorf("%s: object expected, got %s", path, typeOf(v))}
+		}
+		return c.validateObject(path, obj, s)
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return []error{fmt.Errorf("%s: array expected, got %s", path, typeOf(v))}
+		}
+		var errs []error
+		if s.Items != nil {
+			for i, item := range arr {
+				errs = append(errs, c.validate(fmt.Sprintf("%s[%d]", path, i), item, *s.Items)...)
+			}
+		}
+		return errs
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			return []error{fmt.Errorf("%s: string expected, got %s", path, typeOf(v))}
+		}
+		return validateString(path, str, s)
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != float64(int64(n)) {
+			return []error{fmt.Errorf("%s: integer expected, got %s", path, typeOf(v))}
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return []error{fmt.Errorf("%s: number expected, got %s", path, typeOf(v))}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []error{fmt.Errorf("%s: boolean expected, got %s", path, typeOf(v))}
+		}
+	}
+
+	return nil
+}
+
+// validateObject проверяет обязательные и лишние поля объекта и значения его полей
+func (c *Contract) validateObject(path string, obj map[string]any, s schema) []error {
+	var errs []error
+
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			errs = append(errs, fmt.Errorf("%s.%s: required field is missing", path, name))
+		}
+	}
+
+	// сортируем поля, чтобы нарушения выводились в одном и том же порядке
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				errs = append(errs, fmt.Errorf("%s.%s: field is not described in contract", path, name))
+			}
+			continue
+		}
+		if obj[name] == nil && !slices.Contains(s.Required, name) {
+			continue
+		}
+		errs = append(errs, c.validate(path+"."+name, obj[name], prop)...)
+	}
+
+	return errs
+}
+
+// validateString проверяет строку по шаблону и формату схемы
+func validateString(path, str string, s schema) []error {
+	var errs []error
+
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return []error{fmt.Errorf("%s: invalid pattern in contract: %w", path, err)}
+		}
+		if !re.MatchString(str) {
+			errs = append(errs, fmt.Errorf("%s: value %q does not match pattern %s", path, str, s.Pattern))
+		}
+	}
+
+	switch s.Format {
+	case "uri":
+		u, err := url.Parse(str)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: value %q is not an absolute URI", path, str))
+		}
+	}
+
+	return errs
+}
+
+// typeOf возвращает название типа JSON-значения
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
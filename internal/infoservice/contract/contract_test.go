@@ -0,0 +1,154 @@
+package contract
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// validBody ответ, соответствующий контракту
+const validBody = `{"releaseDate":"16-07-2006","text":"Ooh baby, don't you know I suffer?","link":"https://www.youtube.com/watch?v=Xsp3_a-PMTw"}`
+
+func loadContract(t *testing.T) *Contract {
+	t.Helper()
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("failed to load contract: %v", err)
+	}
+	return c
+}
+
+func TestValidateRequest(t *testing.T) {
+	c := loadContract(t)
+
+	tests := []struct {
+		name    string
+		target  string
+		wantErr string
+	}{
+		{name: "valid", target: "/info?group=Muse&song=Uprising"},
+		{name: "renamed parameter", target: "/info?group=Muse&title=Uprising", wantErr: `"song" is required`},
+		{name: "unknown parameter", target: "/info?group=Muse&song=Uprising&year=2009", wantErr: `"year" is not described`},
+		{name: "empty parameter", target: "/info?group=&song=Uprising", wantErr: `"group" is required`},
+		{name: "unknown path", target: "/songs?group=Muse&song=Uprising", wantErr: "not described in contract"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.ValidateRequest(httptest.NewRequest(http.MethodGet, tt.target, nil))
+			checkErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	c := loadContract(t)
+
+	tests := []struct {
+		name        string
+		status      int
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{name: "valid", status: http.StatusOK, contentType: "application/json; charset=utf-8", body: validBody},
+		{name: "renamed field", status: http.StatusOK, contentType: "application/json",
+			body:    `{"release_date":"16-07-2006","text":"t","link":"https://example.com"}`,
+			wantErr: "body.releaseDate: required field is missing"},
+		{name: "iso date", status: http.StatusOK, contentType: "application/json",
+			body:    `{"releaseDate":"2006-07-16","text":"t","link":"https://example.com"}`,
+			wantErr: `body.releaseDate: value "2006-07-16" does not match pattern`},
+		{name: "dotted date", status: http.StatusOK, contentType: "application/json",
+			body:    `{"releaseDate":"16.07.2006","text":"t","link":"https://example.com"}`,
+			wantErr: "does not match pattern"},
+		{name: "month out of range", status: http.StatusOK, contentType: "application/json",
+			body:    `{"releaseDate":"16-13-2006","text":"t","link":"https://example.com"}`,
+			wantErr: "does not match pattern"},
+		{name: "relative link", status: http.StatusOK, contentType: "application/json",
+			body:    `{"releaseDate":"16-07-2006","text":"t","link":"/watch?v=1"}`,
+			wantErr: "is not an absolute URI"},
+		{name: "wrong type", status: http.StatusOK, contentType: "application/json",
+			body:    `{"releaseDate":"16-07-2006","text":["t"],"link":"https://example.com"}`,
+			wantErr: "body.text: string expected, got array"},
+		{name: "not json", status: http.StatusOK, contentType: "application/json", body: `<html></html>`, wantErr: "invalid JSON body"},
+		{name: "wrong content type", status: http.StatusOK, contentType: "text/html", body: validBody, wantErr: `content type "text/html"`},
+		{name: "not found", status: http.StatusNotFound, contentType: "text/plain", body: "song not found"},
+		{name: "undocumented status", status: http.StatusTeapot, contentType: "text/plain", body: "", wantErr: "status 418 is not described"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.ValidateResponse(http.MethodGet, "/info", tt.status, tt.contentType, []byte(tt.body))
+			checkErr(t, err, tt.wantErr)
+		})
+	}
+}
+
+// TestCheck проверяет запросы клиента muslib и ответы сервиса через проверяющий прокси
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		wantViolation string
+	}{
+		{name: "valid", status: http.StatusOK, body: validBody},
+		{name: "not found", status: http.StatusNotFound, body: "song not found"},
+		{name: "renamed field", status: http.StatusOK,
+			body:          `{"releaseDate":"16-07-2006","lyrics":"t","link":"https://example.com"}`,
+			wantViolation: "body.text: required field is missing"},
+		{name: "date not DD-MM-YYYY", status: http.StatusOK,
+			body:          `{"releaseDate":"July 16, 2006","text":"t","link":"https://example.com"}`,
+			wantViolation: "body.releaseDate"},
+	}
+
+	c := loadContract(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer upstream.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			results, err := Check(ctx, c, upstream.URL, time.Second, []Case{{Group: "Muse", Song: "Supermassive Black Hole"}})
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+
+			result := results[0]
+			if result.Status != tt.status {
+				t.Errorf("status = %d, want %d", result.Status, tt.status)
+			}
+			checkErr(t, errors.Join(result.Violations...), tt.wantViolation)
+			if tt.wantViolation == "" && tt.status == http.StatusOK && result.ClientErr != nil {
+				t.Errorf("client rejected a valid response: %v", result.ClientErr)
+			}
+		})
+	}
+}
+
+// checkErr проверяет, что ошибки нет, если want пустая, иначе - что ее текст содержит want
+func checkErr(t *testing.T, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Errorf("unexpected error: %v", err)
+	case want != "" && err == nil:
+		t.Errorf("expected error containing %q, got nil", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Errorf("error %q does not contain %q", err, want)
+	}
+}
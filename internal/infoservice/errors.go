@@ -0,0 +1,40 @@
+package infoservice
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Категории ошибок внешнего сервиса, проверяются через errors.Is
+var (
+	ErrNotFound    = errors.New("song not found in info service")
+	ErrUnavailable = errors.New("info service unavailable")
+	ErrBadPayload  = errors.New("invalid info service response")
+)
+
+// Error ошибка обращения к внешнему сервису
+type Error struct {
+	Kind       error         // категория ошибки: ErrNotFound, ErrUnavailable или ErrBadPayload
+	StatusCode int           // код ответа, если ответ был получен
+	RetryAfter time.Duration // значение заголовка Retry-After, если он был передан
+	Err        error         // исходная ошибка
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.Error()
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(" (status %d)", e.StatusCode)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
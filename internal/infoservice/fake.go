@@ -0,0 +1,62 @@
+package infoservice
+
+import (
+	"context"
+	"sync"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// Fake реализация клиента для тестов, хранит ответы в памяти
+type Fake struct {
+	mu      sync.Mutex
+	details map[string]model.SongDetail
+	calls   int
+
+	// Err, если задана, возвращается на каждый запрос
+	Err error
+}
+
+// NewFake создает пустой фейковый клиент
+func NewFake() *Fake {
+	return &Fake{details: make(map[string]model.SongDetail)}
+}
+
+// Set задает ответ для песни
+func (f *Fake) Set(group, song string, detail model.SongDetail) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.details[fakeKey(group, song)] = detail
+}
+
+// Calls возвращает количество выполненных запросов
+func (f *Fake) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+// GetSongDetail возвращает сохраненный ответ или ErrNotFound
+func (f *Fake) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+
+	if err := ctx.Err(); err != nil {
+		return model.SongDetail{}, &Error{Kind: ErrUnavailable, Err: err}
+	}
+	if f.Err != nil {
+		return model.SongDetail{}, f.Err
+	}
+
+	detail, ok := f.details[fakeKey(group, song)]
+	if !ok {
+		return model.SongDetail{}, &Error{Kind: ErrNotFound}
+	}
+	return detail, nil
+}
+
+func fakeKey(group, song string) string {
+	return group + "\x00" + song
+}
@@ -0,0 +1,156 @@
+package infoservice
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// LimitSettings ограничения запросов к внешнему сервису
+type LimitSettings struct {
+	RPS         float64 // количество запросов в секунду (0 - без ограничения)
+	Burst       int     // количество запросов, которые можно выполнить сразу после простоя
+	MaxInFlight int     // количество одновременных запросов (0 - без ограничения)
+}
+
+// LimitStats статистика ограничителя
+type LimitStats struct {
+	InFlight    int     `json:"in_flight"`
+	Calls       uint64  `json:"calls"`
+	Throttled   uint64  `json:"throttled"`
+	Rejected    uint64  `json:"rejected"`
+	WaitSeconds float64 `json:"wait_seconds"`
+}
+
+// LimitClient ограничивает частоту (token bucket) и количество одновременных запросов
+// к внешнему сервису. Ожидание очереди ограничено контекстом запроса
+type LimitClient struct {
+	next     Client
+	settings LimitSettings
+	slots    chan struct{}
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+
+	calls, throttled, rejected atomic.Uint64
+	waitNanos                  atomic.Int64
+}
+
+// NewLimitClient оборачивает клиента next ограничителем
+func NewLimitClient(next Client, settings LimitSettings) *LimitClient {
+	if settings.Burst < 1 {
+		settings.Burst = 1
+	}
+
+	c := &LimitClient{
+		next:     next,
+		settings: settings,
+		tokens:   float64(settings.Burst),
+		last:     time.Now(),
+	}
+	if settings.MaxInFlight > 0 {
+		c.slots = make(chan struct{}, settings.MaxInFlight)
+	}
+	return c
+}
+
+// GetSongDetail ждет свободного места и разрешения ограничителя и выполняет запрос
+func (c *LimitClient) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	c.calls.Add(1)
+	start := time.Now()
+	waited := false
+
+	// сначала занимаем место среди одновременных запросов, затем ждем разрешения
+	// по частоте, чтобы запросы уходили в сервис не чаще заданного
+	if c.slots != nil {
+		select {
+		case c.slots <- struct{}{}:
+		default:
+			waited = true
+			select {
+			case c.slots <- struct{}{}:
+			case <-ctx.Done():
+				return c.reject(ctx, start)
+			}
+		}
+		defer func() { <-c.slots }()
+	}
+
+	if delay := c.reserve(); delay > 0 {
+		waited = true
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			c.cancel()
+			return c.reject(ctx, start)
+		}
+	}
+
+	if waited {
+		c.throttled.Add(1)
+		c.waitNanos.Add(int64(time.Since(start)))
+	}
+
+	return c.next.GetSongDetail(ctx, group, song)
+}
+
+// Stats возвращает статистику ограничителя
+func (c *LimitClient) Stats() LimitStats {
+	return LimitStats{
+		InFlight:    len(c.slots),
+		Calls:       c.calls.Load(),
+		Throttled:   c.throttled.Load(),
+		Rejected:    c.rejected.Load(),
+		WaitSeconds: time.Duration(c.waitNanos.Load()).Seconds(),
+	}
+}
+
+// reserve забирает разрешение из корзины и возвращает время, через которое им можно воспользоваться
+func (c *LimitClient) reserve() time.Duration {
+	if c.settings.RPS <= 0 {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.tokens += now.Sub(c.last).Seconds() * c.settings.RPS
+	if c.tokens > float64(c.settings.Burst) {
+		c.tokens = float64(c.settings.Burst)
+	}
+	c.last = now
+
+	c.tokens--
+	if c.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-c.tokens / c.settings.RPS * float64(time.Second))
+}
+
+// cancel возвращает в корзину неиспользованное разрешение
+func (c *LimitClient) cancel() {
+	if c.settings.RPS <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens++
+}
+
+// reject учитывает запрос, не дождавшийся разрешения до отмены контекста.
+// Для вызывающих сервис недоступен, как и при таймауте запроса
+func (c *LimitClient) reject(ctx context.Context, start time.Time) (model.SongDetail, error) {
+	c.rejected.Add(1)
+	c.waitNanos.Add(int64(time.Since(start)))
+	return model.SongDetail{}, &Error{Kind: ErrUnavailable, Err: fmt.Errorf("waiting for info service rate limiter: %w", ctx.Err())}
+}
@@ -0,0 +1,108 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimitClientRate(t *testing.T) {
+	next := &stubClient{}
+	c := NewLimitClient(next, LimitSettings{RPS: 20, Burst: 2})
+
+	// запросы в пределах Burst выполняются сразу, следующий ждет пополнения корзины
+	start := time.Now()
+	for range 3 {
+		if _, err := c.GetSongDetail(context.Background(), "Muse", "Uprising"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("elapsed = %s, want at least 40ms", elapsed)
+	}
+
+	stats := c.Stats()
+	if stats.Calls != 3 || stats.Throttled != 1 || stats.Rejected != 0 {
+		t.Errorf("stats = %+v, want 3 calls and 1 throttled", stats)
+	}
+}
+
+func TestLimitClientRejectRefundsToken(t *testing.T) {
+	next := &stubClient{}
+	c := NewLimitClient(next, LimitSettings{RPS: 10, Burst: 1})
+
+	if _, err := c.GetSongDetail(context.Background(), "Muse", "Uprising"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// разрешения придется ждать 100ms, контекст истекает раньше
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetSongDetail(ctx, "Muse", "Uprising")
+	if !errors.Is(err, ErrUnavailable) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want %v", err, ErrUnavailable)
+	}
+	if next.Calls() != 1 {
+		t.Errorf("calls = %d, want 1", next.Calls())
+	}
+	if stats := c.Stats(); stats.Rejected != 1 {
+		t.Errorf("rejected = %d, want 1", stats.Rejected)
+	}
+
+	// неиспользованное разрешение возвращено в корзину: очередь не копится за отмененными запросами
+	c.mu.Lock()
+	tokens := c.tokens
+	c.mu.Unlock()
+	if tokens < -0.5 {
+		t.Errorf("tokens = %.2f, reserved token was not refunded", tokens)
+	}
+}
+
+func TestLimitClientMaxInFlight(t *testing.T) {
+	block := &blockingClient{started: make(chan struct{}, 1)}
+	c := NewLimitClient(block, LimitSettings{MaxInFlight: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.GetSongDetail(ctx, "Muse", "Uprising")
+	}()
+	<-block.started
+
+	if stats := c.Stats(); stats.InFlight != 1 {
+		t.Errorf("in flight = %d, want 1", stats.InFlight)
+	}
+
+	// второй запрос ждет свободного места до истечения своего контекста
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer waitCancel()
+
+	if _, err := c.GetSongDetail(waitCtx, "Muse", "Starlight"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want %v", err, ErrUnavailable)
+	}
+
+	cancel()
+	<-done
+
+	stats := c.Stats()
+	if stats.InFlight != 0 || stats.Rejected != 1 {
+		t.Errorf("stats = %+v, want no requests in flight and 1 rejected", stats)
+	}
+}
+
+func TestLimitClientUnlimited(t *testing.T) {
+	next := &stubClient{}
+	c := NewLimitClient(next, LimitSettings{})
+
+	for range 100 {
+		if _, err := c.GetSongDetail(context.Background(), "Muse", "Uprising"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if stats := c.Stats(); stats.Throttled != 0 || stats.Rejected != 0 {
+		t.Errorf("stats = %+v, want no throttled or rejected requests", stats)
+	}
+}
@@ -0,0 +1,115 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// RetryPolicy параметры повторных запросов к внешнему сервису
+type RetryPolicy struct {
+	MaxAttempts int           // максимальное количество попыток, включая первую
+	BaseDelay   time.Duration // задержка перед первым повтором
+	MaxDelay    time.Duration // максимальная задержка между попытками
+	Jitter      float64       // доля случайного разброса задержки от 0 до 1
+}
+
+// RetryClient повторяет запросы к внешнему сервису с экспоненциальной задержкой
+type RetryClient struct {
+	next   Client
+	policy RetryPolicy
+}
+
+// NewRetryClient оборачивает клиента next политикой повторов
+func NewRetryClient(next Client, policy RetryPolicy) *RetryClient {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Jitter < 0 {
+		policy.Jitter = 0
+	}
+	if policy.Jitter > 1 {
+		policy.Jitter = 1
+	}
+	return &RetryClient{next: next, policy: policy}
+}
+
+// GetSongDetail выполняет запрос, повторяя его при временных ошибках.
+// Ожидание между попытками ограничено контекстом запроса
+func (c *RetryClient) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	var (
+		detail model.SongDetail
+		err    error
+	)
+
+	for attempt := 1; ; attempt++ {
+		detail, err = c.next.GetSongDetail(ctx, group, song)
+		if err == nil || attempt >= c.policy.MaxAttempts || ctx.Err() != nil || !Retryable(err) {
+			return detail, err
+		}
+
+		delay, ok := c.delay(attempt, err)
+		if !ok {
+			return detail, err
+		}
+
+		// не ждем, если повтор все равно не успеет до истечения контекста
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return detail, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return detail, err
+		case <-timer.C:
+		}
+	}
+}
+
+// delay вычисляет задержку перед следующей попыткой с учетом Retry-After.
+// Если сервис просит подождать дольше MaxDelay, повтора не будет: ok равен false
+func (c *RetryClient) delay(attempt int, err error) (d time.Duration, ok bool) {
+	d = c.policy.BaseDelay << (attempt - 1)
+	if d <= 0 || (c.policy.MaxDelay > 0 && d > c.policy.MaxDelay) {
+		d = c.policy.MaxDelay
+	}
+
+	if c.policy.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * c.policy.Jitter * float64(d))
+	}
+
+	var infoErr *Error
+	if errors.As(err, &infoErr) && infoErr.RetryAfter > d {
+		if c.policy.MaxDelay > 0 && infoErr.RetryAfter > c.policy.MaxDelay {
+			return 0, false
+		}
+		d = infoErr.RetryAfter
+	}
+
+	return d, true
+}
+
+// Retryable сообщает, имеет ли смысл повторить запрос после ошибки:
+// повторяются ошибки сети и таймауты, ответы 5xx и 429
+func Retryable(err error) bool {
+	var infoErr *Error
+	if !errors.As(err, &infoErr) || !errors.Is(infoErr.Kind, ErrUnavailable) {
+		return false
+	}
+
+	switch {
+	case infoErr.StatusCode == 0:
+		return true
+	case infoErr.StatusCode == http.StatusTooManyRequests:
+		return true
+	case infoErr.StatusCode >= http.StatusInternalServerError:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package infoservice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// stubClient возвращает ошибки из errs по очереди, после их окончания - успешный ответ
+type stubClient struct {
+	mu    sync.Mutex
+	errs  []error
+	calls int
+}
+
+func (c *stubClient) GetSongDetail(context.Context, string, string) (model.SongDetail, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls++
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		if err != nil {
+			return model.SongDetail{}, err
+		}
+	}
+	return model.SongDetail{Text: "ok"}, nil
+}
+
+func (c *stubClient) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func unavailable(status int, retryAfter time.Duration) error {
+	return &Error{Kind: ErrUnavailable, StatusCode: status, RetryAfter: retryAfter}
+}
+
+func TestRetryClient(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "network error then success", errs: []error{unavailable(0, 0)}, wantCalls: 2},
+		{name: "server errors then success", errs: []error{unavailable(http.StatusBadGateway, 0), unavailable(http.StatusServiceUnavailable, 0)}, wantCalls: 3},
+		{
+			name:      "attempts exhausted",
+			errs:      []error{unavailable(500, 0), unavailable(500, 0), unavailable(500, 0), nil},
+			wantErr:   ErrUnavailable,
+			wantCalls: 3,
+		},
+		{name: "not found is not retried", errs: []error{&Error{Kind: ErrNotFound, StatusCode: 404}}, wantErr: ErrNotFound, wantCalls: 1},
+		{name: "bad payload is not retried", errs: []error{&Error{Kind: ErrBadPayload, StatusCode: 200}}, wantErr: ErrBadPayload, wantCalls: 1},
+		{name: "client error is not retried", errs: []error{unavailable(http.StatusForbidden, 0)}, wantErr: ErrUnavailable, wantCalls: 1},
+		{name: "too many requests within max delay", errs: []error{unavailable(http.StatusTooManyRequests, 20*time.Millisecond)}, wantCalls: 2},
+		{
+			name:      "retry after beyond max delay",
+			errs:      []error{unavailable(http.StatusTooManyRequests, time.Minute)},
+			wantErr:   ErrUnavailable,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &stubClient{errs: tt.errs}
+			detail, err := NewRetryClient(next, policy).GetSongDetail(context.Background(), "Muse", "Uprising")
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && detail.Text != "ok" {
+				t.Errorf("detail = %+v", detail)
+			}
+			if next.Calls() != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", next.Calls(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryClientDelay(t *testing.T) {
+	c := NewRetryClient(nil, RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		want    time.Duration
+		wantOK  bool
+	}{
+		{name: "first retry", attempt: 1, err: unavailable(500, 0), want: 100 * time.Millisecond, wantOK: true},
+		{name: "exponential backoff", attempt: 3, err: unavailable(500, 0), want: 400 * time.Millisecond, wantOK: true},
+		{name: "capped by max delay", attempt: 5, err: unavailable(500, 0), want: time.Second, wantOK: true},
+		{name: "overflow capped by max delay", attempt: 64, err: unavailable(500, 0), want: time.Second, wantOK: true},
+		{name: "retry after longer than backoff", attempt: 1, err: unavailable(429, 700*time.Millisecond), want: 700 * time.Millisecond, wantOK: true},
+		{name: "retry after shorter than backoff", attempt: 3, err: unavailable(429, 10*time.Millisecond), want: 400 * time.Millisecond, wantOK: true},
+		{name: "retry after equal to max delay", attempt: 1, err: unavailable(429, time.Second), want: time.Second, wantOK: true},
+		{name: "retry after beyond max delay", attempt: 1, err: unavailable(429, 2*time.Second), wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := c.delay(tt.attempt, tt.err)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("delay = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryClientJitter(t *testing.T) {
+	c := NewRetryClient(nil, RetryPolicy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: 0.5})
+
+	for range 100 {
+		d, ok := c.delay(1, unavailable(500, 0))
+		if !ok || d < 50*time.Millisecond || d > 100*time.Millisecond {
+			t.Fatalf("delay = %s, %v, want between 50ms and 100ms", d, ok)
+		}
+	}
+}
+
+func TestRetryClientContextDeadline(t *testing.T) {
+	next := &stubClient{errs: []error{unavailable(500, 0)}}
+	c := NewRetryClient(next, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.GetSongDetail(ctx, "Muse", "Uprising")
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("err = %v, want %v", err, ErrUnavailable)
+	}
+	// повтор не успевает до истечения контекста, поэтому ошибка возвращается сразу
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("waited %s before giving up", elapsed)
+	}
+	if next.Calls() != 1 {
+		t.Errorf("calls = %d, want 1", next.Calls())
+	}
+}
@@ -0,0 +1,188 @@
+package lang
+
+import (
+	"bufio"
+	"embed"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Коды поддерживаемых языков (ISO 639-1)
+const (
+	Unknown   = ""
+	Russian   = "ru"
+	Ukrainian = "uk"
+	English   = "en"
+	German    = "de"
+	Spanish   = "es"
+	French    = "fr"
+)
+
+const (
+	maxRank     = 300 // количество учитываемых триграмм текста
+	hintBonus   = 40  // вес одной характерной для языка буквы
+	minLetters  = 10  // минимальное количество букв для определения языка
+	profilesDir = "profiles"
+)
+
+//go:embed profiles/*.txt
+var profilesFS embed.FS
+
+// кандидаты в зависимости от письменности
+var (
+	cyrillicLangs = []string{Russian, Ukrainian}
+	latinLangs    = []string{English, German, Spanish, French}
+)
+
+// буквы, характерные только для одного языка из группы
+var hints = map[string]string{
+	Russian:   "ыэъё",
+	Ukrainian: "іїєґ",
+	German:    "äöüß",
+	Spanish:   "ñ¿¡áíóú",
+	French:    "çœèêëîïûù",
+}
+
+// profiles содержит ранги триграмм для каждого языка
+var profiles = loadProfiles()
+
+// loadProfiles загружает встроенные в бинарный файл профили триграмм.
+// В файлах пробел обозначен символом "_", порядок строк задает ранг
+func loadProfiles() map[string]map[string]int {
+	result := make(map[string]map[string]int)
+
+	for _, code := range append(append([]string{}, cyrillicLangs...), latinLangs...) {
+		f, err := profilesFS.Open(profilesDir + "/" + code + ".txt")
+		if err != nil {
+			panic(err)
+		}
+
+		ranks := make(map[string]int)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			gram := strings.ReplaceAll(strings.TrimSpace(scanner.Text()), "_", " ")
+			if len([]rune(gram)) != 3 {
+				continue
+			}
+			if _, exist := ranks[gram]; !exist {
+				ranks[gram] = len(ranks)
+			}
+		}
+		f.Close()
+
+		result[code] = ranks
+	}
+
+	return result
+}
+
+// Supported проверяет, что код языка может вернуть Detect
+func Supported(code string) bool {
+	for _, c := range cyrillicLangs {
+		if c == code {
+			return true
+		}
+	}
+	for _, c := range latinLangs {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect определяет преобладающий язык текста.
+// Сначала по письменности выбирается группа языков, затем язык внутри группы
+// определяется по расстоянию между профилями триграмм с учетом характерных букв.
+// Если язык определить не удалось, возвращается Unknown
+func Detect(text string) string {
+	text = strings.ToLower(text)
+
+	var cyrillic, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	if cyrillic+latin < minLetters {
+		return Unknown
+	}
+
+	candidates := latinLangs
+	if cyrillic > latin {
+		candidates = cyrillicLangs
+	}
+
+	ranks := textProfile(text)
+
+	best, bestScore := Unknown, 0
+	for _, code := range candidates {
+		score := distance(ranks, profiles[code])
+
+		for _, r := range hints[code] {
+			score -= strings.Count(text, string(r)) * hintBonus
+		}
+
+		if best == Unknown || score < bestScore {
+			best, bestScore = code, score
+		}
+	}
+
+	return best
+}
+
+// textProfile строит ранжированный по частоте список триграмм текста
+func textProfile(text string) []string {
+	counts := make(map[string]int)
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+
+	grams := make([]string, 0, len(counts))
+	for gram := range counts {
+		grams = append(grams, gram)
+	}
+
+	// сортируем по убыванию частоты, при равенстве - лексикографически
+	sort.Slice(grams, func(i, j int) bool {
+		if counts[grams[i]] != counts[grams[j]] {
+			return counts[grams[i]] > counts[grams[j]]
+		}
+		return grams[i] < grams[j]
+	})
+
+	if len(grams) > maxRank {
+		grams = grams[:maxRank]
+	}
+	return grams
+}
+
+// distance вычисляет расстояние "out-of-place" между профилем текста и профилем языка
+func distance(text []string, profile map[string]int) int {
+	var d int
+	for i, gram := range text {
+		rank, exist := profile[gram]
+		if !exist {
+			d += maxRank
+			continue
+		}
+		if rank > i {
+			d += rank - i
+		} else {
+			d += i - rank
+		}
+	}
+	return d
+}
@@ -0,0 +1,309 @@
+// Package linkcheck периодически проверяет доступность ссылок песен
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// userAgent представляется сайтам при проверке ссылок
+const userAgent = "muslib-linkcheck/1.0"
+
+// maxBodyRead количество байт тела ответа GET, которое читается перед закрытием соединения
+const maxBodyRead = 64 << 10
+
+var (
+	// errTooManyRedirects возвращается, если ссылка перенаправляет больше MaxRedirects раз
+	errTooManyRedirects = errors.New("too many redirects")
+	// errForbiddenAddress возвращается при попытке соединиться с адресом внутренней сети
+	errForbiddenAddress = errors.New("address is not allowed")
+)
+
+// reservedPrefixes диапазоны адресов, которые не относятся к публичным сайтам, кроме
+// определяемых методами netip.Addr
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // текущая сеть
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // служебные адреса IETF
+	netip.MustParsePrefix("198.18.0.0/15"), // тестирование производительности
+	netip.MustParsePrefix("240.0.0.0/4"),   // зарезервировано, включая 255.255.255.255
+}
+
+// Storage методы хранилища, используемые проверкой. Реализуется storage.Repository
+type Storage interface {
+	ClaimLinks(ctx context.Context, maxAge time.Duration, limit int) ([]model.LinkCheck, error)
+	SaveLinkCheck(ctx context.Context, check model.LinkCheck, threshold int) error
+}
+
+// Settings параметры проверки ссылок
+type Settings struct {
+	Workers          int           // количество одновременных проверок
+	PollInterval     time.Duration // период поиска ссылок для проверки
+	MaxAge           time.Duration // через сколько ссылка проверяется повторно
+	HostDelay        time.Duration // минимальный интервал между запросами к одному сайту
+	Timeout          time.Duration // таймаут проверки одной ссылки
+	MaxRedirects     int           // максимальное количество перенаправлений
+	FailureThreshold int           // количество неудачных проверок подряд, после которого ссылка недоступна
+	BatchSize        int           // количество ссылок, выбираемых за раз
+}
+
+// Checker проверяет ссылки песен
+type Checker struct {
+	stor     Storage
+	client   *http.Client
+	log      logger.Logger
+	settings Settings
+
+	mu       sync.Mutex
+	hostNext map[string]time.Time // время, раньше которого к сайту не обращаемся
+}
+
+// NewChecker создает проверку ссылок. Соединения с адресами внутренней сети (loopback,
+// RFC 1918, link-local, включая адрес метаданных облака 169.254.169.254) запрещены,
+// в том числе после перенаправления или если имя сайта разрешается в такой адрес
+func NewChecker(stor Storage, log logger.Logger, settings Settings) *Checker {
+	if settings.Workers < 1 {
+		settings.Workers = 1
+	}
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = 1
+	}
+	if settings.BatchSize < settings.Workers {
+		settings.BatchSize = settings.Workers * 10
+	}
+
+	c := &Checker{
+		stor:     stor,
+		log:      log,
+		settings: settings,
+		hostNext: make(map[string]time.Time),
+	}
+	dialer := &net.Dialer{
+		Timeout: settings.Timeout,
+		Control: dialControl,
+	}
+	c.client = &http.Client{
+		// прокси не используется: проверяется адрес, с которым соединяется сам сервис
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: settings.Timeout,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		Timeout: settings.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > settings.MaxRedirects {
+				return errTooManyRedirects
+			}
+			// перенаправления на тот же или другой сайт тоже соблюдают интервал запросов
+			return c.wait(req.Context(), req.URL.Hostname())
+		},
+	}
+
+	return c
+}
+
+// Run проверяет ссылки, пока не отменен контекст
+func (c *Checker) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		checks, err := c.stor.ClaimLinks(ctx, c.settings.MaxAge, c.settings.BatchSize)
+		if err != nil && ctx.Err() == nil {
+			c.log.Sugar.Infow("failed to select links to check", "error", err)
+		}
+
+		if len(checks) > 0 {
+			c.checkAll(ctx, checks)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(c.settings.PollInterval):
+		}
+	}
+}
+
+// checkAll проверяет ссылки в Workers потоков и сохраняет результаты
+func (c *Checker) checkAll(ctx context.Context, checks []model.LinkCheck) {
+	queue := make(chan model.LinkCheck)
+	var wg sync.WaitGroup
+
+	for i := 0; i < c.settings.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for check := range queue {
+				c.Check(ctx, &check)
+				if ctx.Err() != nil {
+					// проверка прервана остановкой сервера, ссылка будет проверена позже
+					continue
+				}
+				if err := c.stor.SaveLinkCheck(ctx, check, c.settings.FailureThreshold); err != nil {
+					c.log.Sugar.Infow("failed to save link check", "song", check.SongID, "url", check.URL, "error", err)
+					continue
+				}
+				if check.Outcome == model.CheckFailed {
+					c.log.Sugar.Infow("link check failed", "song", check.SongID, "url", check.URL, "status", check.Status, "error", check.Error)
+				}
+			}
+		}()
+	}
+
+	for _, check := range checks {
+		select {
+		case queue <- check:
+		case <-ctx.Done():
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// Check проверяет одну ссылку: запрашивает ее методом HEAD, а если сайт не поддерживает HEAD, - методом GET.
+// Перенаправления выполняются, конечный адрес сохраняется в RedirectURL
+func (c *Checker) Check(ctx context.Context, check *model.LinkCheck) {
+	u, err := url.Parse(check.URL)
+	if err != nil {
+		check.Outcome, check.Error = model.CheckFailed, err.Error()
+		return
+	}
+
+	resp, err := c.do(ctx, http.MethodHead, u)
+	if err == nil && headUnsupported(resp.StatusCode) {
+		resp.Body.Close()
+		resp, err = c.do(ctx, http.MethodGet, u)
+	}
+	if err != nil {
+		check.Outcome, check.Error = model.CheckFailed, err.Error()
+		return
+	}
+	defer resp.Body.Close()
+	io.CopyN(io.Discard, resp.Body, maxBodyRead)
+
+	check.Status = resp.StatusCode
+	check.Error = ""
+	if final := resp.Request.URL.String(); final != check.URL {
+		check.RedirectURL = final
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		check.Outcome = model.CheckInconclusive
+	case resp.StatusCode >= http.StatusBadRequest:
+		check.Outcome = model.CheckFailed
+	default:
+		check.Outcome = model.CheckOK
+	}
+}
+
+// do выполняет запрос, соблюдая интервал между запросами к сайту
+func (c *Checker) do(ctx context.Context, method string, u *url.URL) (*http.Response, error) {
+	if err := c.wait(ctx, u.Hostname()); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var uerr *url.Error
+		if errors.As(err, &uerr) && errors.Is(uerr.Err, errTooManyRedirects) {
+			return nil, fmt.Errorf("%w (more than %d)", errTooManyRedirects, c.settings.MaxRedirects)
+		}
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// wait ждет, пока к сайту можно будет обратиться, и резервирует следующий интервал
+func (c *Checker) wait(ctx context.Context, host string) error {
+	c.mu.Lock()
+	now := time.Now()
+	at := c.hostNext[host]
+	if at.Before(now) {
+		at = now
+	}
+	c.hostNext[host] = at.Add(c.settings.HostDelay)
+	c.cleanup(now)
+	c.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cleanup удаляет сайты, интервал которых истек, чтобы карта не росла. Вызывается под mu
+func (c *Checker) cleanup(now time.Time) {
+	if len(c.hostNext) < 1024 {
+		return
+	}
+	for host, at := range c.hostNext {
+		if at.Before(now) {
+			delete(c.hostNext, host)
+		}
+	}
+}
+
+// dialControl запрещает соединения с непубличными адресами. Вызывается для адреса,
+// полученного после разрешения имени, поэтому проверка не зависит от DNS
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errForbiddenAddress, address)
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", errForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// publicAddr проверяет, что адрес может принадлежать публичному сайту
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// headUnsupported возвращает true для ответов, после которых ссылку стоит проверить методом GET:
+// многие сайты не поддерживают HEAD или отвечают на него иначе, чем на GET
+func headUnsupported(status int) bool {
+	switch status {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented, http.StatusForbidden, http.StatusBadRequest, http.StatusNotFound:
+		return true
+	}
+	return false
+}
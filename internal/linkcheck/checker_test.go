@@ -0,0 +1,332 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/logger"
+	"github.com/plasmatrip/muslib/internal/model"
+	"go.uber.org/zap"
+)
+
+// fakeStorage хранит состояние ссылок в памяти по правилам запроса SaveLinkCheck:
+// успешная проверка сбрасывает счетчик неудач, неопределенная его не меняет
+type fakeStorage struct {
+	mu       sync.Mutex
+	failures map[string]int
+	broken   map[string]bool
+	saved    []model.LinkCheck
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{failures: map[string]int{}, broken: map[string]bool{}}
+}
+
+func (s *fakeStorage) ClaimLinks(context.Context, time.Duration, int) ([]model.LinkCheck, error) {
+	return nil, nil
+}
+
+func (s *fakeStorage) SaveLinkCheck(_ context.Context, check model.LinkCheck, threshold int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.saved = append(s.saved, check)
+	switch check.Outcome {
+	case model.CheckOK:
+		s.failures[check.URL], s.broken[check.URL] = 0, false
+	case model.CheckFailed:
+		s.failures[check.URL]++
+		s.broken[check.URL] = s.failures[check.URL] >= threshold
+	}
+	return nil
+}
+
+// newTestChecker создает проверку, которой разрешены соединения с loopback: httptest слушает 127.0.0.1
+func newTestChecker(stor Storage, settings Settings) *Checker {
+	if settings.Timeout == 0 {
+		settings.Timeout = 5 * time.Second
+	}
+	c := NewChecker(stor, logger.Logger{Sugar: zap.NewNop().Sugar()}, settings)
+	c.client.Transport = &http.Transport{}
+	return c
+}
+
+func TestCheck(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/limited", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	// /redirect/N перенаправляет N раз, последним - на /ok
+	mux.HandleFunc("/redirect/{n}", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.PathValue("n"))
+		target := "/ok"
+		if n > 1 {
+			target = "/redirect/" + strconv.Itoa(n-1)
+		}
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name         string
+		url          string
+		outcome      string
+		status       int
+		redirect     string
+		errorPrefix  string
+		wantMethods  []string
+		maxRedirects int
+	}{
+		{name: "ok", url: server.URL + "/ok", outcome: model.CheckOK, status: http.StatusOK},
+		{name: "HEAD not allowed, GET ok", url: server.URL + "/no-head", outcome: model.CheckOK, status: http.StatusOK,
+			wantMethods: []string{http.MethodHead, http.MethodGet}},
+		{name: "not found after GET", url: server.URL + "/gone", outcome: model.CheckFailed, status: http.StatusNotFound},
+		{name: "server error", url: server.URL + "/error", outcome: model.CheckFailed, status: http.StatusInternalServerError},
+		{name: "rate limited", url: server.URL + "/limited", outcome: model.CheckInconclusive, status: http.StatusTooManyRequests},
+		{name: "redirects within limit", url: server.URL + "/redirect/2", outcome: model.CheckOK, status: http.StatusOK,
+			redirect: server.URL + "/ok", maxRedirects: 2},
+		{name: "too many redirects", url: server.URL + "/redirect/3", outcome: model.CheckFailed,
+			errorPrefix: "too many redirects (more than 2)", maxRedirects: 2},
+		{name: "connection refused", url: closed.URL + "/ok", outcome: model.CheckFailed, errorPrefix: "Head"},
+		{name: "invalid url", url: "http://[::1", outcome: model.CheckFailed, errorPrefix: "parse"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			methods = nil
+			mu.Unlock()
+
+			c := newTestChecker(newFakeStorage(), Settings{MaxRedirects: tt.maxRedirects})
+			check := model.LinkCheck{SongID: 1, URL: tt.url}
+			c.Check(context.Background(), &check)
+
+			if check.Outcome != tt.outcome {
+				t.Errorf("outcome = %q, want %q (error %q)", check.Outcome, tt.outcome, check.Error)
+			}
+			if check.Status != tt.status {
+				t.Errorf("status = %d, want %d", check.Status, tt.status)
+			}
+			if check.RedirectURL != tt.redirect {
+				t.Errorf("redirect url = %q, want %q", check.RedirectURL, tt.redirect)
+			}
+			if tt.errorPrefix == "" && check.Error != "" || !strings.HasPrefix(check.Error, tt.errorPrefix) {
+				t.Errorf("error = %q, want prefix %q", check.Error, tt.errorPrefix)
+			}
+			if tt.wantMethods != nil {
+				mu.Lock()
+				got := fmt.Sprint(methods)
+				mu.Unlock()
+				if got != fmt.Sprint(tt.wantMethods) {
+					t.Errorf("methods = %s, want %v", got, tt.wantMethods)
+				}
+			}
+		})
+	}
+}
+
+func TestHostDelay(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	c := newTestChecker(newFakeStorage(), Settings{HostDelay: delay})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := c.wait(ctx, "example.com"); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 2*delay {
+		t.Errorf("three requests to one host took %v, want at least %v", elapsed, 2*delay)
+	}
+
+	// у другого сайта свой интервал
+	start = time.Now()
+	if err := c.wait(ctx, "example.org"); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= delay {
+		t.Errorf("first request to another host waited %v", elapsed)
+	}
+
+	// ожидание прерывается отменой контекста
+	c.wait(ctx, "example.net")
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := c.wait(canceled, "example.net"); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait() with canceled context = %v, want context.Canceled", err)
+	}
+}
+
+// TestHostDelayAppliesToFallback проверяет, что повторный запрос методом GET ждет интервал сайта
+func TestHostDelayAppliesToFallback(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestChecker(newFakeStorage(), Settings{HostDelay: delay})
+	check := model.LinkCheck{URL: server.URL}
+
+	start := time.Now()
+	c.Check(context.Background(), &check)
+	if check.Outcome != model.CheckOK {
+		t.Fatalf("outcome = %q, want ok (error %q)", check.Outcome, check.Error)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("HEAD and GET took %v, want at least %v", elapsed, delay)
+	}
+}
+
+func TestFailureThreshold(t *testing.T) {
+	var status int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	stor := newFakeStorage()
+	c := newTestChecker(stor, Settings{FailureThreshold: 3})
+
+	steps := []struct {
+		status int
+		broken bool
+	}{
+		{status: http.StatusInternalServerError, broken: false},
+		{status: http.StatusBadGateway, broken: false},
+		// ответ 429 счетчик не меняет
+		{status: http.StatusTooManyRequests, broken: false},
+		{status: http.StatusServiceUnavailable, broken: true},
+		{status: http.StatusTooManyRequests, broken: true},
+		// успешная проверка снимает отметку
+		{status: http.StatusOK, broken: false},
+		{status: http.StatusInternalServerError, broken: false},
+	}
+
+	for i, step := range steps {
+		mu.Lock()
+		status = step.status
+		mu.Unlock()
+
+		c.checkAll(context.Background(), []model.LinkCheck{{SongID: 1, URL: server.URL}})
+
+		if got := stor.broken[server.URL]; got != step.broken {
+			t.Fatalf("step %d (status %d): broken = %v, want %v", i+1, step.status, got, step.broken)
+		}
+	}
+	if len(stor.saved) != len(steps) {
+		t.Errorf("saved %d checks, want %d", len(stor.saved), len(steps))
+	}
+
+	// порог меньше единицы заменяется единицей
+	stor = newFakeStorage()
+	c = newTestChecker(stor, Settings{})
+	mu.Lock()
+	status = http.StatusNotFound
+	mu.Unlock()
+	c.checkAll(context.Background(), []model.LinkCheck{{SongID: 1, URL: server.URL}})
+	if !stor.broken[server.URL] {
+		t.Error("link not broken after one failure with default threshold")
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{addr: "93.184.216.34", public: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{addr: "127.0.0.1"},
+		{addr: "127.1.2.3"},
+		{addr: "::1"},
+		{addr: "10.0.0.1"},
+		{addr: "172.16.5.4"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "fd00:ec2::254"},
+		{addr: "0.0.0.0"},
+		{addr: "::"},
+		{addr: "100.64.0.1"},
+		{addr: "224.0.0.1"},
+		{addr: "255.255.255.255"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "::ffff:169.254.169.254"},
+	}
+
+	for _, tt := range tests {
+		if got := publicAddr(netip.MustParseAddr(tt.addr)); got != tt.public {
+			t.Errorf("publicAddr(%s) = %v, want %v", tt.addr, got, tt.public)
+		}
+	}
+}
+
+// TestPrivateAddressesRejected проверяет, что проверка не соединяется с адресами внутренней сети,
+// в том числе по имени сайта, которое разрешается в такой адрес
+func TestPrivateAddressesRejected(t *testing.T) {
+	var requests int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	c := NewChecker(newFakeStorage(), logger.Logger{Sugar: zap.NewNop().Sugar()}, Settings{Timeout: 5 * time.Second})
+
+	for _, u := range []string{server.URL, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)} {
+		check := model.LinkCheck{URL: u}
+		c.Check(context.Background(), &check)
+
+		if check.Outcome != model.CheckFailed || !strings.Contains(check.Error, errForbiddenAddress.Error()) {
+			t.Errorf("%s: outcome %q, error %q, want failed with %q", u, check.Outcome, check.Error, errForbiddenAddress)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if requests != 0 {
+		t.Errorf("server received %d requests", requests)
+	}
+}
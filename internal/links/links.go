@@ -0,0 +1,159 @@
+// Package links разбирает ссылки песен: проверяет их, приводит к каноническому виду
+// и определяет тип по адресу сайта
+package links
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// maxURLLength максимальная длина ссылки в БД
+const maxURLLength = 2048
+
+// ErrInvalidURL возвращается для ссылки, которую нельзя сохранить
+var ErrInvalidURL = errors.New("invalid link")
+
+// kindByHost тип ссылки по адресу сайта, поддомены учитываются
+var kindByHost = map[string]string{
+	"youtube.com":         model.LinkVideo,
+	"youtu.be":            model.LinkVideo,
+	"vimeo.com":           model.LinkVideo,
+	"dailymotion.com":     model.LinkVideo,
+	"rutube.ru":           model.LinkVideo,
+	"music.youtube.com":   model.LinkAudio,
+	"soundcloud.com":      model.LinkAudio,
+	"bandcamp.com":        model.LinkAudio,
+	"open.spotify.com":    model.LinkAudio,
+	"music.apple.com":     model.LinkAudio,
+	"deezer.com":          model.LinkAudio,
+	"tidal.com":           model.LinkAudio,
+	"music.yandex.ru":     model.LinkAudio,
+	"music.yandex.com":    model.LinkAudio,
+	"genius.com":          model.LinkLyrics,
+	"azlyrics.com":        model.LinkLyrics,
+	"musixmatch.com":      model.LinkLyrics,
+	"lyrics.com":          model.LinkLyrics,
+	"lyricstranslate.com": model.LinkLyrics,
+	"itunes.apple.com":    model.LinkPurchase,
+	"amazon.com":          model.LinkPurchase,
+	"beatport.com":        model.LinkPurchase,
+	"7digital.com":        model.LinkPurchase,
+	"qobuz.com":           model.LinkPurchase,
+}
+
+// trackingParams параметры запроса, которые не влияют на содержимое страницы
+var trackingParams = []string{"si", "feature", "fbclid", "gclid", "igshid"}
+
+// Parse проверяет ссылку, приводит ее к каноническому виду и определяет тип.
+// Тип, указанный явно, заменяет определенный по адресу
+func Parse(raw, kind string) (model.Link, error) {
+	raw = strings.TrimSpace(raw)
+	// исходная ссылка тоже сохраняется в БД как основная ссылка песни
+	if len(raw) > maxURLLength {
+		return model.Link{}, fmt.Errorf("%w: link must not exceed %d characters", ErrInvalidURL, maxURLLength)
+	}
+	if kind != "" && !model.ValidLinkKind(kind) {
+		return model.Link{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidURL, kind)
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return model.Link{}, fmt.Errorf("%w: absolute http(s) URL expected, got %q", ErrInvalidURL, raw)
+	}
+
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	host = strings.TrimPrefix(host, "m.")
+
+	link := model.Link{Kind: kindOf(host), Host: host}
+	if link.Kind != model.LinkOther {
+		// известные сайты доступны только по https
+		u.Scheme = "https"
+	}
+	canonical(u, host, &link)
+
+	if kind != "" {
+		link.Kind = kind
+	}
+	if len(link.URL) > maxURLLength {
+		return model.Link{}, fmt.Errorf("%w: link must not exceed %d characters", ErrInvalidURL, maxURLLength)
+	}
+
+	return link, nil
+}
+
+// canonical приводит ссылки известных сайтов к единому виду и извлекает идентификатор медиа,
+// у остальных ссылок удаляет параметры отслеживания
+func canonical(u *url.URL, host string, link *model.Link) {
+	switch {
+	case host == "youtu.be" || host == "youtube.com" || host == "music.youtube.com" || host == "youtube-nocookie.com":
+		if id := model.YouTubeID(u); id != "" {
+			link.MediaID = id
+			link.Host = "youtube.com"
+			if host == "music.youtube.com" {
+				link.Host = host
+				link.URL = "https://music.youtube.com/watch?v=" + id
+			} else {
+				link.URL = "https://www.youtube.com/watch?v=" + id
+			}
+			return
+		}
+	case host == "vimeo.com":
+		if id := lastNumeric(u.Path); id != "" {
+			link.MediaID = id
+			link.URL = "https://vimeo.com/" + id
+			return
+		}
+	case host == "open.spotify.com":
+		if id, err := model.ParseExternalID(model.SchemeSpotify, u.String()); err == nil {
+			link.MediaID = id.Value
+			link.URL = "https://open.spotify.com/track/" + id.Value
+			return
+		}
+	}
+
+	q := u.Query()
+	for name := range q {
+		if strings.HasPrefix(name, "utm_") {
+			q.Del(name)
+		}
+	}
+	for _, name := range trackingParams {
+		q.Del(name)
+	}
+	u.RawQuery = q.Encode()
+	if u.Path == "/" {
+		u.Path = ""
+	}
+	link.URL = u.String()
+}
+
+// kindOf определяет тип ссылки по адресу сайта или его родительскому домену
+func kindOf(host string) string {
+	for h := host; h != ""; {
+		if kind, ok := kindByHost[h]; ok {
+			return kind
+		}
+		i := strings.IndexByte(h, '.')
+		if i < 0 {
+			break
+		}
+		h = h[i+1:]
+	}
+	return model.LinkOther
+}
+
+// lastNumeric возвращает последний сегмент пути, если он состоит из цифр
+func lastNumeric(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	last := segments[len(segments)-1]
+	if last == "" || strings.Trim(last, "0123456789") != "" {
+		return ""
+	}
+	return last
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Схемы внешних идентификаторов
+const (
+	SchemeISRC       = "isrc"        // International Standard Recording Code
+	SchemeMBID       = "mbid"        // MusicBrainz recording ID
+	SchemeSpotify    = "spotify"     // Spotify track ID
+	SchemeYouTube    = "youtube"     // YouTube video ID
+	SchemeAppleMusic = "apple_music" // Apple Music track ID
+	SchemeDeezer     = "deezer"      // Deezer track ID
+)
+
+// ExternalID идентификатор песни во внешнем каталоге
+type ExternalID struct {
+	Scheme string `json:"scheme"`
+	Value  string `json:"value"`
+}
+
+// ErrUnknownScheme возвращается для неизвестной схемы внешнего идентификатора
+var ErrUnknownScheme = errors.New("unknown external id scheme")
+
+var (
+	isrcPattern      = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+	mbidPattern      = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	spotifyPattern   = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+	youTubePattern   = regexp.MustCompile(`^[0-9A-Za-z_-]{11}$`)
+	numericIDPattern = regexp.MustCompile(`^[1-9][0-9]{0,19}$`)
+)
+
+// ParseExternalID проверяет идентификатор и приводит его к каноническому виду.
+// Кроме самих идентификаторов принимаются их привычные записи: ISRC с дефисами
+// и префиксом "ISRC", URN MusicBrainz, URI и ссылки Spotify, ссылки YouTube, Apple Music и Deezer
+func ParseExternalID(scheme, value string) (ExternalID, error) {
+	scheme = strings.ToLower(strings.TrimSpace(scheme))
+	value = strings.TrimSpace(value)
+
+	var (
+		canonical string
+		ok        bool
+	)
+	switch scheme {
+	case SchemeISRC:
+		canonical, ok = parseISRC(value)
+	case SchemeMBID:
+		canonical = strings.ToLower(strings.TrimPrefix(value, "urn:mbid:"))
+		ok = mbidPattern.MatchString(canonical)
+	case SchemeSpotify:
+		canonical = strings.TrimPrefix(value, "spotify:track:")
+		if u, err := url.Parse(value); err == nil && u.Host == "open.spotify.com" {
+			canonical = pathAfter(u.Path, "track")
+		}
+		ok = spotifyPattern.MatchString(canonical)
+	case SchemeYouTube:
+		canonical = value
+		if u, err := url.Parse(value); err == nil && u.Host != "" {
+			canonical = YouTubeID(u)
+		}
+		ok = youTubePattern.MatchString(canonical)
+	case SchemeAppleMusic:
+		canonical = value
+		if u, err := url.Parse(value); err == nil && u.Host == "music.apple.com" {
+			// ссылка на трек в альбоме: /us/album/name/123?i=456, на отдельный трек: /us/song/name/456
+			if canonical = u.Query().Get("i"); canonical == "" {
+				canonical = lastSegment(u.Path)
+			}
+		}
+		ok = numericIDPattern.MatchString(canonical)
+	case SchemeDeezer:
+		canonical = value
+		if u, err := url.Parse(value); err == nil && strings.HasSuffix(u.Host, "deezer.com") {
+			canonical = pathAfter(u.Path, "track")
+		}
+		ok = numericIDPattern.MatchString(canonical)
+	default:
+		return ExternalID{}, fmt.Errorf("%w %q", ErrUnknownScheme, scheme)
+	}
+
+	if !ok {
+		return ExternalID{}, fmt.Errorf("invalid %s %q", scheme, value)
+	}
+
+	return ExternalID{Scheme: scheme, Value: canonical}, nil
+}
+
+// parseISRC проверяет ISRC вида CC-XXX-YY-NNNNN: код страны, код регистранта, год и номер записи.
+// Контрольной цифры в ISRC нет, поэтому проверяется структура кода, а код страны
+// должен состоять из букв (кроме ISO 3166 выдаются коды вроде QM и QZ)
+func parseISRC(value string) (string, bool) {
+	v := strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(value))
+
+	// префикс "ISRC" отбрасывается, только если после него остается полный код:
+	// сам код тоже может начинаться с IS (Исландия), например ISRCA0912345
+	if rest := strings.TrimLeft(strings.TrimPrefix(v, "ISRC"), ":"); len(rest) == 12 {
+		v = rest
+	}
+
+	return v, isrcPattern.MatchString(v)
+}
+
+// YouTubeID возвращает идентификатор видео из ссылки YouTube:
+// youtu.be/ID, youtube.com/watch?v=ID, /embed/ID, /shorts/ID, /live/ID и music.youtube.com.
+// Для других ссылок возвращается пустая строка
+func YouTubeID(u *url.URL) string {
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+
+	var id string
+	switch host {
+	case "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case "youtube.com", "music.youtube.com", "youtube-nocookie.com":
+		if id = u.Query().Get("v"); id == "" {
+			for _, prefix := range []string{"embed", "shorts", "live", "v"} {
+				if id = pathAfter(u.Path, prefix); id != "" {
+					break
+				}
+			}
+		}
+	}
+
+	if !youTubePattern.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+// pathAfter возвращает сегмент пути, следующий за сегментом name
+func pathAfter(path, name string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] == name {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
+
+// lastSegment возвращает последний сегмент пути
+func lastSegment(path string) string {
+	path = strings.Trim(path, "/")
+	return path[strings.LastIndex(path, "/")+1:]
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseExternalID(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "isrc", scheme: "isrc", value: "USRC17607839", want: "USRC17607839"},
+		{name: "isrc with dashes", scheme: "ISRC", value: "us-rc1-76-07839", want: "USRC17607839"},
+		{name: "isrc with prefix", scheme: "isrc", value: "ISRC: US-RC1-76-07839", want: "USRC17607839"},
+		{name: "isrc with prefix and spaces", scheme: "isrc", value: "ISRC US RC1 76 07839", want: "USRC17607839"},
+		{name: "icelandic isrc", scheme: "isrc", value: "ISRCA0912345", want: "ISRCA0912345"},
+		{name: "icelandic isrc with dashes", scheme: "isrc", value: "IS-RCA-09-12345", want: "ISRCA0912345"},
+		{name: "icelandic isrc with prefix", scheme: "isrc", value: "ISRC ISRCA0912345", want: "ISRCA0912345"},
+		{name: "isrc too short", scheme: "isrc", value: "USRC1760783", wantErr: true},
+		{name: "isrc digit country", scheme: "isrc", value: "1SRC17607839", wantErr: true},
+
+		{name: "mbid", scheme: "mbid", value: "B1A9C0E9-D987-4042-AE91-78D6A3267D69", want: "b1a9c0e9-d987-4042-ae91-78d6a3267d69"},
+		{name: "mbid urn", scheme: "mbid", value: "urn:mbid:b1a9c0e9-d987-4042-ae91-78d6a3267d69", want: "b1a9c0e9-d987-4042-ae91-78d6a3267d69"},
+		{name: "mbid without dashes", scheme: "mbid", value: "b1a9c0e9d9874042ae9178d6a3267d69", wantErr: true},
+
+		{name: "spotify", scheme: "spotify", value: "4uLU6hMCjMI75M1A2tKUQC", want: "4uLU6hMCjMI75M1A2tKUQC"},
+		{name: "spotify uri", scheme: "spotify", value: "spotify:track:4uLU6hMCjMI75M1A2tKUQC", want: "4uLU6hMCjMI75M1A2tKUQC"},
+		{name: "spotify url", scheme: "spotify", value: "https://open.spotify.com/track/4uLU6hMCjMI75M1A2tKUQC?si=abc", want: "4uLU6hMCjMI75M1A2tKUQC"},
+		{name: "spotify localized url", scheme: "spotify", value: "https://open.spotify.com/intl-de/track/4uLU6hMCjMI75M1A2tKUQC", want: "4uLU6hMCjMI75M1A2tKUQC"},
+		{name: "spotify album url", scheme: "spotify", value: "https://open.spotify.com/album/4uLU6hMCjMI75M1A2tKUQC", wantErr: true},
+
+		{name: "youtube", scheme: "youtube", value: "dQw4w9WgXcQ", want: "dQw4w9WgXcQ"},
+		{name: "youtube watch url", scheme: "youtube", value: "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42", want: "dQw4w9WgXcQ"},
+		{name: "youtube short url", scheme: "youtube", value: "https://youtu.be/dQw4w9WgXcQ", want: "dQw4w9WgXcQ"},
+		{name: "youtube shorts url", scheme: "youtube", value: "https://youtube.com/shorts/dQw4w9WgXcQ", want: "dQw4w9WgXcQ"},
+		{name: "youtube music url", scheme: "youtube", value: "https://music.youtube.com/watch?v=dQw4w9WgXcQ", want: "dQw4w9WgXcQ"},
+		{name: "youtube other site", scheme: "youtube", value: "https://vimeo.com/dQw4w9WgXcQ", wantErr: true},
+
+		{name: "apple music", scheme: "apple_music", value: "1440857781", want: "1440857781"},
+		{name: "apple music album url", scheme: "apple_music", value: "https://music.apple.com/us/album/uprising/1440857325?i=1440857781", want: "1440857781"},
+		{name: "apple music song url", scheme: "apple_music", value: "https://music.apple.com/us/song/uprising/1440857781", want: "1440857781"},
+		{name: "apple music leading zero", scheme: "apple_music", value: "01440857781", wantErr: true},
+
+		{name: "deezer", scheme: "deezer", value: "3135556", want: "3135556"},
+		{name: "deezer url", scheme: "deezer", value: "https://www.deezer.com/en/track/3135556", want: "3135556"},
+		{name: "deezer album url", scheme: "deezer", value: "https://www.deezer.com/en/album/302127", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseExternalID(tt.scheme, tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseExternalID(%q, %q) = %+v, want error", tt.scheme, tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Value != tt.want {
+				t.Errorf("value = %q, want %q", got.Value, tt.want)
+			}
+			if got.Scheme != strings.ToLower(tt.scheme) {
+				t.Errorf("scheme = %q, want %q", got.Scheme, tt.scheme)
+			}
+		})
+	}
+}
+
+func TestParseExternalIDUnknownScheme(t *testing.T) {
+	if _, err := ParseExternalID("discogs", "123"); !errors.Is(err, ErrUnknownScheme) {
+		t.Fatalf("err = %v, want %v", err, ErrUnknownScheme)
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// Типы ссылок песни
+const (
+	LinkVideo    = "video"    // клип или видео с исполнением
+	LinkAudio    = "audio"    // прослушивание на стриминговом сервисе
+	LinkLyrics   = "lyrics"   // источник текста песни
+	LinkPurchase = "purchase" // покупка
+	LinkOther    = "other"    // остальные ссылки
+)
+
+// Link ссылка песни
+type Link struct {
+	URL  string `json:"url"`
+	Kind string `json:"kind"`
+	Host string `json:"host"`
+	// MediaID идентификатор видео или трека на сайте, если его удалось извлечь из ссылки
+	MediaID string `json:"media_id,omitempty"`
+	// LastChecked время последней проверки доступности ссылки
+	LastChecked *time.Time `json:"last_checked,omitempty"`
+	// Status код ответа сайта при последней проверке
+	Status int `json:"status,omitempty"`
+	// CheckError ошибка последней проверки
+	CheckError string `json:"check_error,omitempty"`
+	// RedirectURL адрес, на который перенаправляет ссылка
+	RedirectURL string `json:"redirect_url,omitempty"`
+	// Broken ссылка недоступна несколько проверок подряд
+	Broken bool `json:"broken,omitempty"`
+}
+
+// ValidLinkKind проверяет тип ссылки
+func ValidLinkKind(kind string) bool {
+	switch kind {
+	case LinkVideo, LinkAudio, LinkLyrics, LinkPurchase, LinkOther:
+		return true
+	}
+	return false
+}
+
+// Итоги проверки ссылки
+const (
+	CheckOK           = "ok"           // ссылка доступна
+	CheckFailed       = "failed"       // ссылка недоступна
+	CheckInconclusive = "inconclusive" // сайт ограничил частоту запросов, доступность неизвестна
+)
+
+// LinkCheck результат проверки доступности ссылки
+type LinkCheck struct {
+	SongID      int
+	URL         string
+	Outcome     string
+	Status      int
+	RedirectURL string
+	Error       string
+}
+
+// BrokenLink недоступная ссылка в отчете
+type BrokenLink struct {
+	SongID int    `json:"song_id"`
+	Group  string `json:"group"`
+	Song   string `json:"song"`
+	Link
+	Failures int `json:"failures"`
+}
+
+// LinkReport отчет о проверке ссылок
+type LinkReport struct {
+	Total      int            `json:"total"`
+	Checked    int            `json:"checked"`
+	Broken     int            `json:"broken"`
+	Redirected int            `json:"redirected"`
+	ByStatus   map[string]int `json:"by_status"`
+	// BrokenLinks недоступные ссылки с пагинацией
+	BrokenLinks []BrokenLink `json:"broken_links"`
+}
@@ -1,20 +1,108 @@
 package model
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"strings"
 	"time"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 type Song struct {
+	ID    int    `json:"id,omitempty"`
 	Group string `json:"group"`
 	Song  string `json:"song"`
+	Lang  string `json:"lang,omitempty"`
 	SongDetail
+	Explicit      bool           `json:"explicit"`
+	ExplicitTerms map[string]int `json:"explicit_terms,omitempty"`
+	// EnrichmentPending песня сохранена без данных внешнего сервиса и ожидает их получения
+	EnrichmentPending bool `json:"enrichment_pending,omitempty"`
+	// EnrichedAt время последнего получения данных из внешнего сервиса
+	EnrichedAt *time.Time `json:"enriched_at,omitempty"`
+	// PayloadHash хэш данных, полученных из внешнего сервиса
+	PayloadHash string `json:"payload_hash,omitempty"`
+	// Links ссылки песни с типами, включая основную ссылку link
+	Links []Link `json:"links,omitempty"`
+	// ExternalIDs идентификаторы песни во внешних каталогах
+	ExternalIDs []ExternalID `json:"external_ids,omitempty"`
+}
+
+// ImportProgress позиция, до которой обработан файл импорта.
+// Fingerprint описывает файл: при его изменении импорт начинается заново
+type ImportProgress struct {
+	Name        string
+	Fingerprint string
+	Position    int64
+}
+
+// MarshalJSON добавляет в ответ источники полей песни. При разборе запросов источники
+// не читаются, чтобы клиент не мог их подменить
+func (s Song) MarshalJSON() ([]byte, error) {
+	type song Song
+	return json.Marshal(struct {
+		song
+		Sources map[string]string `json:"sources,omitempty"`
+	}{song(s), s.Sources})
+}
+
+// Normalize приводит названия группы и песни к каноническому виду
+func (s *Song) Normalize() {
+	s.Group = NormalizeName(s.Group)
+	s.Song = NormalizeName(s.Song)
+}
+
+// NormalizeName приводит название к форме NFC, удаляет пробелы по краям
+// и заменяет последовательности пробельных символов одним пробелом
+func NormalizeName(name string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(name)), " ")
 }
 
 type SongDetail struct {
 	ReleaseDate ReleaseDate `json:"releaseDate"`
 	Text        string      `json:"text,omitempty"`
 	Link        string      `json:"link,omitempty"`
+	// Sources источник каждого заполненного поля: название источника данных или "manual".
+	// Заполняется только сервисом: из запросов и ответов источников не читается, в ответах API выводится в Song
+	Sources map[string]string `json:"-"`
+}
+
+// PayloadHash возвращает хэш полученных от источника данных полей песни.
+// Совпадение хэшей означает, что данные у источника не менялись
+func (d SongDetail) PayloadHash() string {
+	var date string
+	if !d.ReleaseDate.Time.IsZero() {
+		date = d.ReleaseDate.String()
+	}
+
+	h := sha256.New()
+	for _, v := range []string{date, d.Text, d.Link} {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// FieldChange изменение поля песни при повторном получении данных
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// EnrichResult результат повторного получения данных песни из внешнего сервиса
+type EnrichResult struct {
+	ID          int           `json:"id"`
+	Group       string        `json:"group"`
+	Song        string        `json:"song"`
+	Changes     []FieldChange `json:"changes,omitempty"`
+	PayloadHash string        `json:"payload_hash,omitempty"`
+	// Stale данные у источника изменились с последнего получения
+	Stale   bool   `json:"stale"`
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
 }
 
 type Filter struct {
@@ -22,10 +110,118 @@ type Filter struct {
 	Song        *string
 	Text        *string
 	Link        *string
+	Lang        *string
+	Explicit    *bool
 	ReleaseFrom *time.Time
 	ReleaseTo   *time.Time
-	Limit       int
-	Page        int
+	// LinkKind песни, у которых есть ссылка указанного типа
+	LinkKind *string
+	// BrokenLink песни с недоступной ссылкой (true) или без таких ссылок (false)
+	BrokenLink *bool
+	// ExternalID песня с указанным внешним идентификатором
+	ExternalID *ExternalID
+	// EnrichedBefore песни, данные которых не получались из внешнего сервиса после указанного времени
+	EnrichedBefore *time.Time
+	Limit          int
+	Page           int
+}
+
+// ExplicitOverride ручная установка признака ненормативной лексики.
+// Значение null снимает ручную установку
+type ExplicitOverride struct {
+	Group    string `json:"group"`
+	Song     string `json:"song"`
+	Explicit *bool  `json:"explicit"`
+}
+
+// Normalize приводит названия группы и песни к каноническому виду
+func (o *ExplicitOverride) Normalize() {
+	o.Group = NormalizeName(o.Group)
+	o.Song = NormalizeName(o.Song)
+}
+
+// CachedDetail сохраненный ответ внешнего сервиса
+type CachedDetail struct {
+	Detail    SongDetail
+	NotFound  bool
+	ExpiresAt time.Time
+}
+
+// Статусы заданий на получение данных из внешнего сервиса
+const (
+	JobPending = "pending" // ожидает выполнения
+	JobRunning = "running" // выполняется
+	JobDone    = "done"    // выполнено
+	JobDead    = "dead"    // попытки исчерпаны, требуется ручной перезапуск
+)
+
+// Job задание на получение данных песни из внешнего сервиса
+type Job struct {
+	ID          int64     `json:"id"`
+	SongID      int       `json:"song_id"`
+	Group       string    `json:"group"`
+	Song        string    `json:"song"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+	RunAt       time.Time `json:"run_at"`
+	LastError   string    `json:"last_error,omitempty"`
+	Refresh     bool      `json:"refresh"` // запрос к внешнему сервису в обход кэша
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Режимы импорта песен, которые уже есть в библиотеке
+const (
+	ImportInsert = "insert" // ошибка для существующей песни
+	ImportUpsert = "upsert" // обновить существующую песню
+	ImportSkip   = "skip"   // пропустить существующую песню
+)
+
+// Результаты импорта строки
+const (
+	ImportInserted = "inserted"
+	ImportUpdated  = "updated"
+	ImportSkipped  = "skipped"
+	ImportFailed   = "failed"
+)
+
+// ImportRowResult результат импорта одной строки
+type ImportRowResult struct {
+	Line   int    `json:"line"`
+	Group  string `json:"group,omitempty"`
+	Song   string `json:"song,omitempty"`
+	Status string `json:"status"`
+	ID     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ImportReport отчет об импорте песен
+type ImportReport struct {
+	Total    int               `json:"total"`
+	Inserted int               `json:"inserted"`
+	Updated  int               `json:"updated"`
+	Skipped  int               `json:"skipped"`
+	Failed   int               `json:"failed"`
+	Rows     []ImportRowResult `json:"rows"`
+	// Error ошибка разметки, на которой чтение данных прекращено
+	Error string `json:"error,omitempty"`
+}
+
+// Add учитывает результат строки в отчете
+func (r *ImportReport) Add(row ImportRowResult) {
+	r.Total++
+	switch row.Status {
+	case ImportInserted:
+		r.Inserted++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	default:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, row)
 }
 
 type VerseResponse struct {
@@ -36,7 +232,47 @@ type VerseResponse struct {
 	TotalVerses int    `json:"total_verses"`
 }
 
-type ReleaseDate time.Time
+// DatePrecision точность даты релиза
+type DatePrecision string
+
+const (
+	PrecisionYear  DatePrecision = "year"
+	PrecisionMonth DatePrecision = "month"
+	PrecisionDay   DatePrecision = "day"
+)
+
+// форматы даты релиза для каждой точности; первый формат используется для вывода
+var releaseDateFormats = []struct {
+	layout    string
+	precision DatePrecision
+}{
+	{"02-01-2006", PrecisionDay},
+	{"01-2006", PrecisionMonth},
+	{"2006", PrecisionYear},
+	{"2006-01-02", PrecisionDay},
+	{"2006-01", PrecisionMonth},
+	{time.RFC3339, PrecisionDay},
+}
+
+// ReleaseDate дата релиза с учетом известной точности
+type ReleaseDate struct {
+	Time      time.Time
+	Precision DatePrecision
+}
+
+// ParseReleaseDate разбирает дату релиза в одном из форматов:
+// "1975", "03-1975", "02-03-1975", а также ISO 8601 ("1975-03", "1975-03-02")
+func ParseReleaseDate(value string) (ReleaseDate, error) {
+	var lastErr error
+	for _, f := range releaseDateFormats {
+		t, err := time.Parse(f.layout, value)
+		if err == nil {
+			return ReleaseDate{Time: t, Precision: f.precision}, nil
+		}
+		lastErr = err
+	}
+	return ReleaseDate{}, lastErr
+}
 
 func (c *ReleaseDate) UnmarshalJSON(b []byte) error {
 	value := strings.Trim(string(b), `"`)
@@ -44,21 +280,53 @@ func (c *ReleaseDate) UnmarshalJSON(b []byte) error {
 		return nil
 	}
 
-	t, err := time.Parse("02-01-2006", value)
+	rd, err := ParseReleaseDate(value)
 	if err != nil {
 		return err
 	}
-	*c = ReleaseDate(t)
+	*c = rd
 	return nil
 }
 
 func (c ReleaseDate) MarshalJSON() ([]byte, error) {
-	return []byte(`"` + time.Time(c).Format("02-01-2006") + `"`), nil
+	return []byte(`"` + c.String() + `"`), nil
+}
+
+// String возвращает дату с сохраненной точностью
+func (c ReleaseDate) String() string {
+	switch c.Precision {
+	case PrecisionYear:
+		return c.Time.Format("2006")
+	case PrecisionMonth:
+		return c.Time.Format("01-2006")
+	default:
+		return c.Time.Format("02-01-2006")
+	}
+}
+
+// End возвращает последний момент периода, заданного датой с ее точностью
+func (c ReleaseDate) End() time.Time {
+	switch c.Precision {
+	case PrecisionYear:
+		return c.Time.AddDate(1, 0, 0).Add(-time.Nanosecond)
+	case PrecisionMonth:
+		return c.Time.AddDate(0, 1, 0).Add(-time.Nanosecond)
+	default:
+		return c.Time.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+}
+
+// PrecisionOrDefault возвращает точность даты, по умолчанию - до дня
+func (c ReleaseDate) PrecisionOrDefault() DatePrecision {
+	if c.Precision == "" {
+		return PrecisionDay
+	}
+	return c.Precision
 }
 
 func (c ReleaseDate) NilIfZero() interface{} {
-	if time.Time(c).IsZero() {
+	if c.Time.IsZero() {
 		return nil
 	}
-	return time.Time(c)
+	return c.Time
 }
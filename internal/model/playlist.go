@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Playlist сохраненный плейлист
+type Playlist struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	// SongIDs идентификаторы песен по порядку; песня может встречаться несколько раз
+	SongIDs   []int     `json:"song_ids"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,227 @@
+// Package musicbrainz читает JSON-дампы MusicBrainz (https://musicbrainz.org/doc/Development/JSON_Data_Dumps).
+// Дамп сущности - файл mbdump/<сущность> из архива <сущность>.tar.xz, в каждой строке которого
+// записан один JSON-объект
+package musicbrainz
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// Source источник даты релиза, полученной из MusicBrainz
+const Source = "musicbrainz"
+
+// Сущности дампа
+const (
+	EntityArtist    = "artist"
+	EntityRelease   = "release"
+	EntityRecording = "recording"
+)
+
+// maxNameLength максимальная длина названия в БД
+const maxNameLength = 255
+
+// Entity определяет сущность дампа по имени файла: artist, release или recording,
+// допускаются расширения .json, .jsonl и .gz. Для остальных файлов возвращается пустая строка
+func Entity(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".gz")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".jsonl"), ".json")
+	switch name {
+	case EntityArtist, EntityRelease, EntityRecording:
+		return name
+	}
+	return ""
+}
+
+// Reader читает дамп построчно
+type Reader struct {
+	file *os.File
+	gz   *gzip.Reader
+	r    *bufio.Reader
+	line int64
+}
+
+// Open открывает файл дампа, файлы с расширением .gz распаковываются
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := &Reader{file: f}
+	var src io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		rd.gz, err = gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		src = rd.gz
+	}
+	rd.r = bufio.NewReaderSize(src, 1<<20)
+
+	return rd, nil
+}
+
+// Fingerprint описывает файл дампа размером и временем изменения.
+// Новый дамп на месте старого получает другой отпечаток
+func (rd *Reader) Fingerprint() (string, error) {
+	fi, err := rd.file.Stat()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d:%d", fi.Size(), fi.ModTime().UnixNano()), nil
+}
+
+// Next возвращает номер очередной строки и ее содержимое, в конце файла - io.EOF.
+// Строки релизов могут занимать мегабайты, поэтому длина строки не ограничивается
+func (rd *Reader) Next() (int64, []byte, error) {
+	for {
+		data, err := rd.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return rd.line, nil, err
+		}
+		rd.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 {
+			return rd.line, data, nil
+		}
+		if err != nil {
+			return rd.line, nil, err
+		}
+	}
+}
+
+// Close закрывает файл дампа
+func (rd *Reader) Close() error {
+	if rd.gz != nil {
+		rd.gz.Close()
+	}
+	return rd.file.Close()
+}
+
+type artistCredit struct {
+	Name       string `json:"name"`
+	JoinPhrase string `json:"joinphrase"`
+	Artist     struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+}
+
+type recording struct {
+	ID               string         `json:"id"`
+	Title            string         `json:"title"`
+	ArtistCredit     []artistCredit `json:"artist-credit"`
+	FirstReleaseDate string         `json:"first-release-date"`
+}
+
+type release struct {
+	Date         string         `json:"date"`
+	ArtistCredit []artistCredit `json:"artist-credit"`
+	Media        []struct {
+		Tracks []struct {
+			Title        string         `json:"title"`
+			ArtistCredit []artistCredit `json:"artist-credit"`
+			Recording    recording      `json:"recording"`
+		} `json:"tracks"`
+	} `json:"media"`
+}
+
+// Songs преобразует строку дампа в песни библиотеки: запись (recording) - одна песня,
+// релиз - песни всех его треков с датой релиза. Песня хранит MBID записи в ExternalIDs.
+// Исполнители не содержат песен, для них возвращается пустой список.
+// Записи без исполнителя или названия и с названиями длиннее допустимого пропускаются
+func Songs(entity string, data []byte) ([]model.Song, error) {
+	switch entity {
+	case EntityRecording:
+		var rec recording
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return nil, err
+		}
+		if song, ok := newSong(rec, rec.ArtistCredit, rec.FirstReleaseDate); ok {
+			return []model.Song{song}, nil
+		}
+		return nil, nil
+	case EntityRelease:
+		var rel release
+		if err := json.Unmarshal(data, &rel); err != nil {
+			return nil, err
+		}
+		var songs []model.Song
+		for _, m := range rel.Media {
+			for _, t := range m.Tracks {
+				rec := t.Recording
+				// у трека в дампе релиза запись может быть без названия и исполнителя
+				if rec.Title == "" {
+					rec.Title = t.Title
+				}
+				credit := rec.ArtistCredit
+				if len(credit) == 0 {
+					credit = t.ArtistCredit
+				}
+				if len(credit) == 0 {
+					credit = rel.ArtistCredit
+				}
+				// из даты первого релиза записи и даты этого релиза берется более ранняя при сохранении
+				date := rel.Date
+				if date == "" {
+					date = rec.FirstReleaseDate
+				}
+				if song, ok := newSong(rec, credit, date); ok {
+					songs = append(songs, song)
+				}
+			}
+		}
+		return songs, nil
+	case EntityArtist:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown entity %q", entity)
+	}
+}
+
+// newSong формирует песню из записи. Некорректная дата отбрасывается
+func newSong(rec recording, credit []artistCredit, date string) (model.Song, bool) {
+	song := model.Song{Group: creditName(credit), Song: rec.Title}
+	song.Normalize()
+
+	mbid, err := model.ParseExternalID(model.SchemeMBID, rec.ID)
+	if err != nil || song.Group == "" || song.Song == "" ||
+		utf8.RuneCountInString(song.Group) > maxNameLength || utf8.RuneCountInString(song.Song) > maxNameLength {
+		return song, false
+	}
+
+	if date != "" {
+		if rd, err := model.ParseReleaseDate(date); err == nil {
+			song.ReleaseDate = rd
+		}
+	}
+	song.ExternalIDs = []model.ExternalID{mbid}
+
+	return song, true
+}
+
+// creditName собирает имя исполнителя так, как оно указано в записи, например "Jay-Z feat. Rihanna"
+func creditName(credit []artistCredit) string {
+	var b strings.Builder
+	for _, c := range credit {
+		name := c.Name
+		if name == "" {
+			name = c.Artist.Name
+		}
+		b.WriteString(name)
+		b.WriteString(c.JoinPhrase)
+	}
+	return b.String()
+}
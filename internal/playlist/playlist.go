@@ -0,0 +1,143 @@
+package playlist
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// Форматы плейлистов
+const (
+	FormatM3U  = "m3u"  // расширенный M3U
+	FormatXSPF = "xspf" // XML Shareable Playlist Format
+	FormatJSPF = "jspf" // JSON-вариант XSPF
+)
+
+// ContentTypes типы содержимого для каждого формата
+var ContentTypes = map[string]string{
+	FormatM3U:  "audio/x-mpegurl; charset=utf-8",
+	FormatXSPF: "application/xspf+xml",
+	FormatJSPF: "application/json",
+}
+
+// Write записывает песни в плейлист формата format.
+// Название трека берется из названия песни, исполнитель - из названия группы,
+// адрес - из ссылки. В M3U песни без ссылки пропускаются: формат требует адрес у каждой записи
+func Write(w io.Writer, format, title string, songs []model.Song) error {
+	switch format {
+	case FormatM3U:
+		return writeM3U(w, title, songs)
+	case FormatXSPF:
+		return writeXSPF(w, title, songs)
+	case FormatJSPF:
+		return writeJSPF(w, title, songs)
+	}
+	return fmt.Errorf("unsupported playlist format %q", format)
+}
+
+// writeM3U записывает расширенный M3U
+func writeM3U(w io.Writer, title string, songs []model.Song) error {
+	var b strings.Builder
+
+	b.WriteString("#EXTM3U\n")
+	if title != "" {
+		fmt.Fprintf(&b, "#PLAYLIST:%s\n", oneLine(title))
+	}
+	for _, s := range songs {
+		if s.Link == "" {
+			continue
+		}
+		// длительность неизвестна, по соглашению указывается -1
+		fmt.Fprintf(&b, "#EXTINF:-1,%s - %s\n%s\n", oneLine(s.Group), oneLine(s.Song), s.Link)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// oneLine заменяет переводы строк, которые ломают построчный формат M3U
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Version string      `xml:"version,attr"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Date    string      `xml:"date"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Title    string `xml:"title"`
+	Creator  string `xml:"creator"`
+}
+
+// writeXSPF записывает плейлист XSPF версии 1
+func writeXSPF(w io.Writer, title string, songs []model.Song) error {
+	p := xspfPlaylist{
+		Version: "1",
+		XMLNS:   "http://xspf.org/ns/0/",
+		Title:   title,
+		Date:    time.Now().Format(time.RFC3339),
+		Tracks:  make([]xspfTrack, 0, len(songs)),
+	}
+	for _, s := range songs {
+		p.Tracks = append(p.Tracks, xspfTrack{Location: s.Link, Title: s.Song, Creator: s.Group})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type jspfDocument struct {
+	Playlist jspfPlaylist `json:"playlist"`
+}
+
+type jspfPlaylist struct {
+	Title string      `json:"title,omitempty"`
+	Date  string      `json:"date"`
+	Track []jspfTrack `json:"track"`
+}
+
+type jspfTrack struct {
+	Location []string `json:"location,omitempty"`
+	Title    string   `json:"title"`
+	Creator  string   `json:"creator"`
+}
+
+// writeJSPF записывает плейлист JSPF
+func writeJSPF(w io.Writer, title string, songs []model.Song) error {
+	doc := jspfDocument{Playlist: jspfPlaylist{
+		Title: title,
+		Date:  time.Now().Format(time.RFC3339),
+		Track: make([]jspfTrack, 0, len(songs)),
+	}}
+	for _, s := range songs {
+		track := jspfTrack{Title: s.Song, Creator: s.Group}
+		if s.Link != "" {
+			track.Location = []string{s.Link}
+		}
+		doc.Playlist.Track = append(doc.Playlist.Track, track)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/musicbrainz"
+)
+
+// Поля расширенной информации, для которых учитывается источник
+const (
+	FieldReleaseDate = "releaseDate"
+	FieldText        = "text"
+	FieldLink        = "link"
+)
+
+// SourceManual источник полей, заданных вручную. Такие поля не заменяются данными других источников
+const SourceManual = "manual"
+
+// SourceRanks приоритет источников данных песни: поле не заменяется данными источника
+// с более низким приоритетом. У источников, которых нет в списке, приоритет 0
+var SourceRanks = map[string]int{
+	SourceManual:       2,
+	musicbrainz.Source: 1,
+}
+
+// Replaces сообщает, заменяют ли данные источника source поле, заполненное источником current
+func Replaces(source, current string) bool {
+	return SourceRanks[source] >= SourceRanks[current]
+}
+
+// Provider именованный источник расширенной информации о песнях
+type Provider struct {
+	Name   string
+	Client infoservice.Client
+}
+
+// Chain опрашивает источники в порядке приоритета и объединяет их ответы:
+// каждое поле берется из первого источника, который его заполнил.
+// Источники, вернувшие "не найдено", пропускаются. Если хотя бы один
+// источник недоступен, возвращается его ошибка, чтобы запрос был повторен
+// позже и данные не остались неполными
+type Chain struct {
+	providers []Provider
+}
+
+// NewChain создает цепочку источников, первый источник имеет наивысший приоритет
+func NewChain(providers ...Provider) *Chain {
+	return &Chain{providers: providers}
+}
+
+// GetSongDetail возвращает объединенные данные и заполняет Sources - источник каждого поля
+func (c *Chain) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	var merged model.SongDetail
+	merged.Sources = make(map[string]string)
+
+	for _, p := range c.providers {
+		if complete(merged) {
+			break
+		}
+
+		detail, err := p.Client.GetSongDetail(ctx, group, song)
+		if errors.Is(err, infoservice.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return model.SongDetail{}, err
+		}
+
+		if merged.ReleaseDate.Time.IsZero() && !detail.ReleaseDate.Time.IsZero() {
+			merged.ReleaseDate = detail.ReleaseDate
+			merged.Sources[FieldReleaseDate] = p.Name
+		}
+		if merged.Text == "" && detail.Text != "" {
+			merged.Text = detail.Text
+			merged.Sources[FieldText] = p.Name
+		}
+		if merged.Link == "" && detail.Link != "" {
+			merged.Link = detail.Link
+			merged.Sources[FieldLink] = p.Name
+		}
+	}
+
+	if len(merged.Sources) == 0 {
+		return model.SongDetail{}, &infoservice.Error{Kind: infoservice.ErrNotFound}
+	}
+
+	return merged, nil
+}
+
+// complete сообщает, что все поля уже заполнены
+func complete(d model.SongDetail) bool {
+	return !d.ReleaseDate.Time.IsZero() && d.Text != "" && d.Link != ""
+}
@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// FileProvider источник данных из локального файла JSON или CSV.
+// JSON - массив объектов с полями group, song, releaseDate, text, link.
+// CSV - первая строка содержит названия тех же колонок в любом порядке
+type FileProvider struct {
+	songs map[string]model.SongDetail
+}
+
+// NewFileProvider загружает данные из файла, формат определяется по расширению
+func NewFileProvider(path string) (*FileProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open metadata file: %w", err)
+	}
+	defer f.Close()
+
+	var songs []model.Song
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&songs)
+	case ".csv":
+		songs, err = readCSV(f)
+	default:
+		err = errors.New("unsupported metadata file format, .json or .csv expected")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata file %s: %w", path, err)
+	}
+
+	p := &FileProvider{songs: make(map[string]model.SongDetail, len(songs))}
+	for _, s := range songs {
+		p.songs[infoservice.CacheKey(s.Group, s.Song)] = s.SongDetail
+	}
+
+	return p, nil
+}
+
+// GetSongDetail возвращает данные песни из файла
+func (p *FileProvider) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	detail, ok := p.songs[infoservice.CacheKey(group, song)]
+	if !ok {
+		return model.SongDetail{}, &infoservice.Error{Kind: infoservice.ErrNotFound}
+	}
+	return detail, nil
+}
+
+// readCSV читает песни из CSV с заголовком
+func readCSV(r io.Reader) ([]model.Song, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"group", "song"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+	}
+
+	value := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var songs []model.Song
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		song := model.Song{Group: value(record, "group"), Song: value(record, "song")}
+		song.Text = value(record, "text")
+		song.Link = value(record, "link")
+		if v := value(record, "releaseDate"); v != "" {
+			song.ReleaseDate, err = model.ParseReleaseDate(v)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", len(songs)+2, err)
+			}
+		}
+
+		songs = append(songs, song)
+	}
+
+	return songs, nil
+}
@@ -0,0 +1,159 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/plasmatrip/muslib/internal/infoservice"
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// maxBodySize ограничение размера ответа источника
+const maxBodySize = 1 << 20
+
+// MappedHTTPProvider источник данных с произвольной JSON-схемой.
+// Адрес задается шаблоном с подстановками {group} и {song},
+// поля ответа сопоставляются с полями песни через пути вида "data.track.lyrics"
+type MappedHTTPProvider struct {
+	urlTemplate string
+	mapping     map[string][]string
+	dateLayout  string
+	client      *http.Client
+}
+
+// ParseMapping разбирает сопоставление полей вида "releaseDate=data.released,text=lyrics.body,link=url"
+func ParseMapping(value string) (map[string][]string, error) {
+	mapping := make(map[string][]string)
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		field, path, ok := strings.Cut(pair, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid mapping %q, field=path expected", pair)
+		}
+
+		switch field {
+		case FieldReleaseDate, FieldText, FieldLink:
+		default:
+			return nil, fmt.Errorf("unknown field %q in mapping", field)
+		}
+
+		mapping[field] = strings.Split(path, ".")
+	}
+
+	if len(mapping) == 0 {
+		return nil, errors.New("empty mapping")
+	}
+
+	return mapping, nil
+}
+
+// NewMappedHTTPProvider создает источник. dateLayout - формат даты в терминах Go,
+// если не задан, дата разбирается как в основном сервисе
+func NewMappedHTTPProvider(urlTemplate string, mapping map[string][]string, dateLayout string, timeout time.Duration) *MappedHTTPProvider {
+	return &MappedHTTPProvider{
+		urlTemplate: urlTemplate,
+		mapping:     mapping,
+		dateLayout:  dateLayout,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+// GetSongDetail запрашивает данные песни и преобразует ответ по сопоставлению полей
+func (p *MappedHTTPProvider) GetSongDetail(ctx context.Context, group, song string) (model.SongDetail, error) {
+	var detail model.SongDetail
+
+	fullURL := strings.NewReplacer(
+		"{group}", url.QueryEscape(group),
+		"{song}", url.QueryEscape(song),
+	).Replace(p.urlTemplate)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return detail, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return detail, &infoservice.Error{Kind: infoservice.ErrUnavailable, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case resp.StatusCode == http.StatusNotFound:
+		return detail, &infoservice.Error{Kind: infoservice.ErrNotFound, StatusCode: resp.StatusCode}
+	default:
+		return detail, &infoservice.Error{Kind: infoservice.ErrUnavailable, StatusCode: resp.StatusCode}
+	}
+
+	var body any
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxBodySize)).Decode(&body); err != nil {
+		return detail, &infoservice.Error{Kind: infoservice.ErrBadPayload, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if v := lookup(body, p.mapping[FieldText]); v != "" {
+		detail.Text = v
+	}
+	if v := lookup(body, p.mapping[FieldLink]); v != "" {
+		detail.Link = v
+	}
+	if v := lookup(body, p.mapping[FieldReleaseDate]); v != "" {
+		if detail.ReleaseDate, err = p.parseDate(v); err != nil {
+			return model.SongDetail{}, &infoservice.Error{Kind: infoservice.ErrBadPayload, StatusCode: resp.StatusCode, Err: err}
+		}
+	}
+
+	if err := infoservice.Validate(detail); err != nil {
+		return model.SongDetail{}, &infoservice.Error{Kind: infoservice.ErrBadPayload, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	return detail, nil
+}
+
+// parseDate разбирает дату в формате источника
+func (p *MappedHTTPProvider) parseDate(value string) (model.ReleaseDate, error) {
+	if p.dateLayout == "" {
+		return model.ParseReleaseDate(value)
+	}
+
+	t, err := time.Parse(p.dateLayout, value)
+	if err != nil {
+		return model.ReleaseDate{}, err
+	}
+	return model.ReleaseDate{Time: t, Precision: model.PrecisionDay}, nil
+}
+
+// lookup возвращает строковое или числовое значение по пути в JSON-документе
+func lookup(doc any, path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+
+	for _, key := range path {
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return ""
+		}
+		doc = obj[key]
+	}
+
+	switch v := doc.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package rating
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+//go:embed words/*.txt
+var wordsFS embed.FS
+
+// Rater проверяет тексты песен на наличие ненормативной лексики
+type Rater struct {
+	words map[string]struct{} // слова, которые должны совпасть целиком
+	stems []string            // основы слов, совпадающие по префиксу
+}
+
+// NewRater загружает списки слов из каталога dir (файлы *.txt).
+// Если каталог не задан, используются встроенные списки.
+// Формат файла: одно слово на строку, "*" в конце задает основу слова,
+// строки, начинающиеся с "#", считаются комментариями
+func NewRater(dir string) (*Rater, error) {
+	var fsys fs.FS = wordsFS
+	root := "words"
+	if dir != "" {
+		fsys = os.DirFS(dir)
+		root = "."
+	}
+
+	files, err := fs.Glob(fsys, path.Join(root, "*.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list word lists: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no word lists found in %q", dir)
+	}
+
+	r := &Rater{words: make(map[string]struct{})}
+	for _, name := range files {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open word list: %w", err)
+		}
+		err = r.load(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read word list %s: %w", name, err)
+		}
+	}
+
+	// длинные основы проверяем первыми, чтобы в статистику попадала наиболее точная
+	sort.Slice(r.stems, func(i, j int) bool {
+		return len(r.stems[i]) > len(r.stems[j])
+	})
+
+	return r, nil
+}
+
+// load читает один список слов
+func (r *Rater) load(f io.Reader) error {
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if stem, ok := strings.CutSuffix(line, "*"); ok {
+			r.stems = append(r.stems, normalize(stem))
+			continue
+		}
+		r.words[normalize(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Rate возвращает найденные в тексте термины и количество их вхождений.
+// Пустой результат означает, что ненормативная лексика не найдена
+func (r *Rater) Rate(text string) map[string]int {
+	matches := make(map[string]int)
+
+	words := strings.FieldsFunc(normalize(text), func(c rune) bool {
+		return !unicode.IsLetter(c)
+	})
+	for _, word := range words {
+		if _, exist := r.words[word]; exist {
+			matches[word]++
+			continue
+		}
+		for _, stem := range r.stems {
+			if strings.HasPrefix(word, stem) {
+				matches[stem+"*"]++
+				break
+			}
+		}
+	}
+
+	return matches
+}
+
+// RateSong проверяет текст песни и заполняет признак ненормативной лексики
+func (r *Rater) RateSong(song *model.Song) {
+	song.Explicit = false
+	song.ExplicitTerms = nil
+
+	if r == nil || song.Text == "" {
+		return
+	}
+
+	if terms := r.Rate(song.Text); len(terms) > 0 {
+		song.Explicit = true
+		song.ExplicitTerms = terms
+	}
+}
+
+// normalize приводит текст к нижнему регистру и заменяет "ё" на "е"
+func normalize(s string) string {
+	return strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+}
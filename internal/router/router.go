@@ -4,37 +4,68 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/plasmatrip/muslib/internal/api/handlers"
 	"github.com/plasmatrip/muslib/internal/api/middleware"
-	"github.com/plasmatrip/muslib/internal/config"
 	"github.com/plasmatrip/muslib/internal/logger"
-	"github.com/plasmatrip/muslib/internal/storage"
 )
 
 // NewRouter создает новый маршрутизатор
-func NewRouter(cfg config.Config, log logger.Logger, stor storage.Repository) *chi.Mux {
+func NewRouter(log logger.Logger, handlers *handlers.Handlers) *chi.Mux {
 
 	r := chi.NewRouter()
 
-	handlers := handlers.Handlers{Config: cfg, Logger: log, Stor: stor}
-
 	r.Use(middleware.WithLogging(log), middleware.WithCompression(log))
 
 	r.Route("/info", func(r chi.Router) {
 		r.Get("/", handlers.Info)
+		r.Get("/cache", handlers.CacheStats)
+		r.Get("/limiter", handlers.LimiterStats)
 	})
 
 	r.Route("/song", func(r chi.Router) {
 		r.Post("/", handlers.AddSong)
 		r.Put("/", handlers.UpdateSong)
 		r.Delete("/", handlers.DeleteSong)
+		r.Put("/explicit", handlers.SetExplicit)
 	})
 
 	r.Route("/songs", func(r chi.Router) {
 		r.Get("/", handlers.GetSongs)
+		r.Get("/export", handlers.ExportSongs)
+		r.Get("/playlist", handlers.GetPlaylist)
+		r.Post("/enrich", handlers.EnrichSongs)
+		r.Post("/{id}/enrich", handlers.EnrichSong)
+		r.Get("/by-isrc/{isrc}", handlers.SongByISRC)
+		r.Get("/by-mbid/{mbid}", handlers.SongByMBID)
+		r.Get("/by-external-id/{scheme}/{value}", handlers.SongByExternalID)
+		r.Post("/{id}/links", handlers.AddLink)
+		r.Delete("/{id}/links", handlers.DeleteLink)
+		r.Post("/{id}/external-ids", handlers.AddExternalID)
+		r.Delete("/{id}/external-ids/{scheme}/{value}", handlers.DeleteExternalID)
+	})
+
+	r.Post("/songs:import", handlers.ImportSongs)
+
+	r.Route("/playlists", func(r chi.Router) {
+		r.Get("/", handlers.GetPlaylists)
+		r.Post("/", handlers.CreatePlaylist)
+		r.Get("/{id}", handlers.SavedPlaylist)
+		r.Put("/{id}", handlers.UpdatePlaylist)
+		r.Delete("/{id}", handlers.DeletePlaylist)
+		r.Get("/{id}/export", handlers.ExportPlaylist)
 	})
 
 	r.Route("/lyrics", func(r chi.Router) {
 		r.Get("/", handlers.GetLyrics)
 	})
 
+	r.Route("/links", func(r chi.Router) {
+		r.Get("/report", handlers.LinkReport)
+	})
+
+	r.Route("/jobs", func(r chi.Router) {
+		r.Get("/", handlers.GetJobs)
+		r.Post("/retry", handlers.RetryDeadJobs)
+		r.Post("/{id}/retry", handlers.RetryJob)
+	})
+
 	return r
 }
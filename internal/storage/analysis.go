@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+)
+
+// analyzeBatchSize количество песен, обрабатываемых за один запрос
+const analyzeBatchSize = 500
+
+// AnalyzeSongs пересчитывает язык и признак ненормативной лексики всех песен. analyze получает песню
+// с текстом и заполняет Lang, Explicit и ExplicitTerms. Песни читаются порциями по идентификатору,
+// каждая порция сохраняется отдельно. Возвращает количество обработанных песен
+func (r Repository) AnalyzeSongs(ctx context.Context, analyze func(*model.Song)) (int, error) {
+	after, total := 0, 0
+
+	for {
+		rows, err := r.db.Query(ctx, queries.SelectSongTexts, pgx.NamedArgs{
+			"after": after,
+			"limit": analyzeBatchSize,
+		})
+		if err != nil {
+			return total, err
+		}
+		songs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Song, error) {
+			var s model.Song
+			err := row.Scan(&s.ID, &s.Text)
+			return s, err
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to read song texts: %w", err)
+		}
+		if len(songs) == 0 {
+			return total, nil
+		}
+
+		batch := &pgx.Batch{}
+		for i := range songs {
+			analyze(&songs[i])
+			batch.Queue(queries.UpdateTextAnalysis, pgx.NamedArgs{
+				"id":             songs[i].ID,
+				"lang":           songs[i].Lang,
+				"explicit":       songs[i].Explicit,
+				"explicit_terms": songs[i].ExplicitTerms,
+			})
+		}
+		if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+			return total, fmt.Errorf("failed to save text analysis: %w", err)
+		}
+
+		total += len(songs)
+		after = songs[len(songs)-1].ID
+	}
+}
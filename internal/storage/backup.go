@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// backupBatchSize количество строк, читаемых из курсора или вставляемых за один запрос
+const backupBatchSize = 500
+
+// migrationsTable таблица версий схемы golang-migrate, в резервную копию не входит
+const migrationsTable = "schema_migrations"
+
+var (
+	// ErrNotEmpty возвращается при восстановлении в БД, в которой уже есть данные
+	ErrNotEmpty = errors.New("database is not empty")
+	// ErrDirtySchema возвращается, если последняя миграция схемы не завершилась
+	ErrDirtySchema = errors.New("database schema is dirty")
+)
+
+// Dumper получает содержимое таблиц при выгрузке БД
+type Dumper interface {
+	// Table вызывается перед строками очередной таблицы
+	Table(name string) error
+	// Row получает строку таблицы в виде JSON-объекта
+	Row(data []byte) error
+}
+
+// Dump выгружает все таблицы БД, кроме таблицы версий схемы, в порядке зависимостей
+// внешних ключей и возвращает версию схемы. Таблицы читаются в одном снимке БД порциями через курсор
+func (r Repository) Dump(ctx context.Context, d Dumper) (uint, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly, IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var (
+		version uint
+		dirty   bool
+	)
+	if err := tx.QueryRow(ctx, `SELECT version, dirty FROM `+migrationsTable).Scan(&version, &dirty); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d", ErrDirtySchema, version)
+	}
+
+	tables, err := listTables(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, table := range tables {
+		if err := d.Table(table); err != nil {
+			return 0, err
+		}
+
+		cursor := fmt.Sprintf("backup_%d", i)
+		if _, err := tx.Exec(ctx, `DECLARE `+cursor+` NO SCROLL CURSOR FOR SELECT row_to_json(t)::text FROM `+
+			pgx.Identifier{table}.Sanitize()+` t`); err != nil {
+			return 0, fmt.Errorf("failed to declare cursor for %s: %w", table, err)
+		}
+
+		for {
+			rows, err := tx.Query(ctx, fmt.Sprintf(`FETCH FORWARD %d FROM %s`, backupBatchSize, cursor))
+			if err != nil {
+				return 0, fmt.Errorf("failed to fetch rows of %s: %w", table, err)
+			}
+
+			fetched := 0
+			for rows.Next() {
+				var data []byte
+				if err := rows.Scan(&data); err != nil {
+					rows.Close()
+					return 0, err
+				}
+				fetched++
+
+				if err := d.Row(data); err != nil {
+					rows.Close()
+					return 0, err
+				}
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return 0, err
+			}
+
+			if fetched < backupBatchSize {
+				break
+			}
+		}
+	}
+
+	return version, tx.Commit(ctx)
+}
+
+// Restore загружает таблицы в одной транзакции. rows передает в fn строки таблицы в виде JSON-объектов.
+// Если truncate равен false, таблицы должны быть пустыми, иначе их содержимое удаляется.
+// После загрузки счетчики serial-столбцов продолжаются с максимальных значений
+func (r Repository) Restore(ctx context.Context, tables []string, truncate bool, rows func(table string, fn func([]byte) error) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	existing, err := listTables(ctx, tx)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(existing))
+	for _, t := range existing {
+		known[t] = true
+	}
+	for _, t := range tables {
+		if !known[t] {
+			return fmt.Errorf("table %s does not exist in the database", t)
+		}
+	}
+
+	if truncate {
+		ids := make([]string, 0, len(existing))
+		for _, t := range existing {
+			ids = append(ids, pgx.Identifier{t}.Sanitize())
+		}
+		if len(ids) > 0 {
+			if _, err := tx.Exec(ctx, `TRUNCATE `+strings.Join(ids, ", ")+` RESTART IDENTITY CASCADE`); err != nil {
+				return fmt.Errorf("failed to truncate tables: %w", err)
+			}
+		}
+	} else if err := checkEmpty(ctx, tx, existing); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		id := pgx.Identifier{table}.Sanitize()
+		insert := `INSERT INTO ` + id + ` SELECT * FROM json_populate_recordset(NULL::` + id + `, $1::json)`
+
+		batch := make([]byte, 0, 64<<10)
+		count := 0
+		flush := func() error {
+			if count == 0 {
+				return nil
+			}
+			batch = append(batch, ']')
+			if _, err := tx.Exec(ctx, insert, string(batch)); err != nil {
+				return fmt.Errorf("failed to load rows into %s: %w", table, err)
+			}
+			batch, count = batch[:0], 0
+			return nil
+		}
+
+		err := rows(table, func(data []byte) error {
+			if count == 0 {
+				batch = append(batch, '[')
+			} else {
+				batch = append(batch, ',')
+			}
+			batch = append(batch, data...)
+			count++
+			if count >= backupBatchSize {
+				return flush()
+			}
+			return nil
+		})
+		if err == nil {
+			err = flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := resetSequences(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// CheckEmpty возвращает ErrNotEmpty, если хотя бы в одной таблице есть строки
+func (r Repository) CheckEmpty(ctx context.Context) error {
+	tables, err := listTables(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	return checkEmpty(ctx, r.db, tables)
+}
+
+// checkEmpty проверяет, что в таблицах нет строк
+func checkEmpty(ctx context.Context, q querier, tables []string) error {
+	for _, t := range tables {
+		var exists bool
+		if err := q.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+pgx.Identifier{t}.Sanitize()+`)`).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: table %s has rows", ErrNotEmpty, t)
+		}
+	}
+	return nil
+}
+
+// listTables возвращает таблицы текущей схемы, кроме таблицы версий, так, что таблица
+// идет после таблиц, на которые ссылается внешними ключами
+func listTables(ctx context.Context, q querier) ([]string, error) {
+	rows, err := q.Query(ctx, `
+		SELECT tablename FROM pg_tables
+		WHERE schemaname = current_schema() AND tablename <> $1
+		ORDER BY tablename`, migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	rows, err = q.Query(ctx, `
+		SELECT DISTINCT c.conrelid::regclass::text, c.confrelid::regclass::text
+		FROM pg_constraint c
+		JOIN pg_namespace n ON n.oid = c.connamespace
+		WHERE c.contype = 'f' AND n.nspname = current_schema() AND c.conrelid <> c.confrelid`)
+	if err != nil {
+		return nil, err
+	}
+	deps := map[string][]string{}
+	for rows.Next() {
+		var table, ref string
+		if err := rows.Scan(&table, &ref); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		deps[table] = append(deps[table], ref)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// топологическая сортировка, при равенстве - по имени
+	ordered := make([]string, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+	for len(ordered) < len(tables) {
+		progress := false
+		for _, t := range tables {
+			if done[t] {
+				continue
+			}
+			ready := true
+			for _, ref := range deps[t] {
+				if !done[ref] && slices.Contains(tables, ref) {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				ordered = append(ordered, t)
+				done[t] = true
+				progress = true
+			}
+		}
+		if !progress {
+			return nil, errors.New("circular foreign key dependencies between tables")
+		}
+	}
+
+	return ordered, nil
+}
+
+// resetSequences продолжает счетчики столбцов serial и identity с максимальных загруженных значений
+func resetSequences(ctx context.Context, q querier) error {
+	rows, err := q.Query(ctx, `
+		SELECT table_name, column_name
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND (column_default LIKE 'nextval(%' OR is_identity = 'YES')`)
+	if err != nil {
+		return err
+	}
+	type column struct{ table, name string }
+	columns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (column, error) {
+		var c column
+		err := row.Scan(&c.table, &c.name)
+		return c, err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range columns {
+		table, col := pgx.Identifier{c.table}.Sanitize(), pgx.Identifier{c.name}.Sanitize()
+		_, err := q.Exec(ctx, `SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(max(`+col+`), 1), max(`+col+`) IS NOT NULL) FROM `+table,
+			table, c.name)
+		if err != nil {
+			return fmt.Errorf("failed to reset sequence of %s.%s: %w", c.table, c.name, err)
+		}
+	}
+
+	return nil
+}
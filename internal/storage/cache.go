@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+)
+
+// GetCachedDetail возвращает сохраненный ответ внешнего сервиса
+func (r Repository) GetCachedDetail(ctx context.Context, key string) (model.CachedDetail, bool, error) {
+	var entry model.CachedDetail
+	var payload *model.SongDetail
+
+	err := r.db.QueryRow(ctx, queries.SelectCachedDetail, pgx.NamedArgs{
+		"key": key,
+	}).Scan(&payload, &entry.NotFound, &entry.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return entry, false, nil
+	}
+	if err != nil {
+		return entry, false, err
+	}
+
+	if payload != nil {
+		entry.Detail = *payload
+	}
+
+	return entry, true, nil
+}
+
+// PutCachedDetail сохраняет ответ внешнего сервиса
+func (r Repository) PutCachedDetail(ctx context.Context, key string, entry model.CachedDetail) error {
+	var payload *model.SongDetail
+	if !entry.NotFound {
+		payload = &entry.Detail
+	}
+
+	_, err := r.db.Exec(ctx, queries.UpsertCachedDetail, pgx.NamedArgs{
+		"key":        key,
+		"payload":    payload,
+		"not_found":  entry.NotFound,
+		"expires_at": entry.ExpiresAt,
+	})
+	return err
+}
+
+// DeleteExpiredCache удаляет просроченные записи кэша
+func (r Repository) DeleteExpiredCache(ctx context.Context) (int64, error) {
+	ct, err := r.db.Exec(ctx, queries.DeleteExpiredCache)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+)
+
+// exportFetchSize количество песен, читаемых из курсора за один запрос
+const exportFetchSize = 500
+
+// ExportSongs передает в fn все песни, подходящие под фильтр (лимит и страница фильтра
+// не учитываются), в порядке добавления. Песни читаются порциями через курсор на стороне БД,
+// поэтому память не зависит от размера библиотеки. Внешние идентификаторы и ссылки загружаются
+// для каждой порции. Ошибка fn прерывает выгрузку
+func (r Repository) ExportSongs(ctx context.Context, filter *model.Filter, fn func(model.Song) error) error {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly, IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query, args := filterSongs(filter)
+
+	// курсор объявляется в простом протоколе: параметры подставляются на стороне клиента
+	// и DECLARE не нужно подготавливать на сервере
+	args = append([]interface{}{pgx.QueryExecModeSimpleProtocol}, args...)
+	if _, err := tx.Exec(ctx, `DECLARE songs_export NO SCROLL CURSOR FOR `+query+` ORDER BY id`, args...); err != nil {
+		return fmt.Errorf("failed to declare export cursor: %w", err)
+	}
+
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf(`FETCH FORWARD %d FROM songs_export`, exportFetchSize))
+		if err != nil {
+			return fmt.Errorf("failed to fetch songs: %w", err)
+		}
+
+		// порция читается целиком: пока результат FETCH не прочитан, в транзакции нельзя
+		// выполнить запросы внешних идентификаторов и ссылок
+		songs := make([]model.Song, 0, exportFetchSize)
+		for rows.Next() {
+			song, err := scanSong(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			songs = append(songs, song)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		if err := loadExternalIDs(ctx, tx, songs); err != nil {
+			return err
+		}
+		if err := loadLinks(ctx, tx, songs); err != nil {
+			return err
+		}
+
+		for _, song := range songs {
+			if err := fn(song); err != nil {
+				return err
+			}
+		}
+
+		if len(songs) < exportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+)
+
+// foreignKeyViolation код ошибки PostgreSQL при ссылке на несуществующую запись
+const foreignKeyViolation = "23503"
+
+var (
+	// ErrExternalIDExists возвращается, если идентификатор уже принадлежит другой песне
+	ErrExternalIDExists = errors.New("external id belongs to another song")
+	// ErrExternalIDNotFound возвращается при удалении идентификатора, которого нет у песни
+	ErrExternalIDNotFound = errors.New("external id not found")
+)
+
+// AddExternalID добавляет песне внешний идентификатор. Повторное добавление
+// идентификатора той же песне не считается ошибкой
+func (r Repository) AddExternalID(ctx context.Context, songID int, id model.ExternalID) error {
+	return addExternalID(ctx, r.db, songID, id)
+}
+
+// addExternalID добавляет песне внешний идентификатор в пуле соединений или транзакции
+func addExternalID(ctx context.Context, q querier, songID int, id model.ExternalID) error {
+	var owner int
+	err := q.QueryRow(ctx, queries.AddExternalID, pgx.NamedArgs{
+		"song_id": songID,
+		"scheme":  id.Scheme,
+		"value":   id.Value,
+	}).Scan(&owner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrExternalIDExists
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrSongNotFound
+	}
+
+	return err
+}
+
+// DeleteExternalID удаляет внешний идентификатор песни
+func (r Repository) DeleteExternalID(ctx context.Context, songID int, id model.ExternalID) error {
+	ct, err := r.db.Exec(ctx, queries.DeleteExternalID, pgx.NamedArgs{
+		"song_id": songID,
+		"scheme":  id.Scheme,
+		"value":   id.Value,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ct.RowsAffected() == 0 {
+		return ErrExternalIDNotFound
+	}
+
+	return nil
+}
+
+// loadExternalIDs заполняет внешние идентификаторы песен одним запросом
+func loadExternalIDs(ctx context.Context, q querier, songs []model.Song) error {
+	if len(songs) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(songs))
+	ids := make([]int, 0, len(songs))
+	for i, s := range songs {
+		index[s.ID] = i
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := q.Query(ctx, queries.SelectExternalIDs, pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var songID int
+		var id model.ExternalID
+		if err := rows.Scan(&songID, &id.Scheme, &id.Value); err != nil {
+			return err
+		}
+		if i, ok := index[songID]; ok {
+			songs[i].ExternalIDs = append(songs[i].ExternalIDs, id)
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/providers"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+	"github.com/plasmatrip/muslib/internal/translit"
+)
+
+// ImportSongs добавляет песни одним пакетом запросов в режиме mode (insert, upsert или skip).
+// Если enrich равен true, для добавленных песен ставятся задания на получение данных.
+// Возвращает результат для каждой песни в том же порядке. Пакет выполняется
+// в одной транзакции: при ошибке БД не сохраняется ни одна песня пакета.
+// Ссылки с типами сохраняются следующим пакетом
+func (r Repository) ImportSongs(ctx context.Context, songs []model.Song, mode string, enrich bool, maxAttempts int) ([]model.ImportRowResult, error) {
+	query := queries.ImportSong
+	if mode == model.ImportUpsert {
+		query = queries.UpsertSong
+	}
+
+	batch := &pgx.Batch{}
+	for _, song := range songs {
+		batch.Queue(query, pgx.NamedArgs{
+			"group_name":        song.Group,
+			"song_name":         song.Song,
+			"group_key":         translit.Key(song.Group),
+			"song_key":          translit.Key(song.Song),
+			"release_date":      song.ReleaseDate.Time,
+			"release_precision": string(song.ReleaseDate.PrecisionOrDefault()),
+			"release_set":       !song.ReleaseDate.Time.IsZero(),
+			"lyrics":            song.Text,
+			"link":              song.Link,
+			"lang":              song.Lang,
+			"explicit":          song.Explicit,
+			"explicit_terms":    song.ExplicitTerms,
+			"detail_sources":    sourcesOrEmpty(song.Sources),
+			"release_field":     providers.FieldReleaseDate,
+			"source_ranks":      providers.SourceRanks,
+			"enrich":            enrich,
+			"max_attempts":      maxAttempts,
+		})
+	}
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	results := make([]model.ImportRowResult, 0, len(songs))
+	prevLinks := make([]string, len(songs))
+	for i, song := range songs {
+		result := model.ImportRowResult{Group: song.Group, Song: song.Song}
+
+		var inserted bool
+		err := br.QueryRow().Scan(&result.ID, &inserted, &prevLinks[i])
+		switch {
+		case errors.Is(err, pgx.ErrNoRows) && mode == model.ImportSkip:
+			result.Status = model.ImportSkipped
+		case errors.Is(err, pgx.ErrNoRows):
+			result.Status = model.ImportFailed
+			result.Error = ErrSongExists.Error()
+		case err != nil:
+			return nil, fmt.Errorf("failed to import song %d of batch: %w", i+1, err)
+		case inserted:
+			result.Status = model.ImportInserted
+		default:
+			result.Status = model.ImportUpdated
+		}
+
+		results = append(results, result)
+	}
+	if err := br.Close(); err != nil {
+		return nil, err
+	}
+
+	// основные ссылки сохраненных песен добавляем в ссылки с типами вместо прежних
+	linkBatch := &pgx.Batch{}
+	for i, result := range results {
+		if result.Status == model.ImportInserted || result.Status == model.ImportUpdated {
+			queueReplaceLink(linkBatch, result.ID, prevLinks[i], songs[i].Link)
+		}
+	}
+	if linkBatch.Len() > 0 {
+		if err := r.db.SendBatch(ctx, linkBatch).Close(); err != nil {
+			return nil, fmt.Errorf("failed to save typed links: %w", err)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/providers"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+)
+
+var (
+	// ErrNoJobs возвращается, если в очереди нет готовых к выполнению заданий
+	ErrNoJobs = errors.New("no jobs ready")
+	// ErrJobNotRetryable возвращается при перезапуске отсутствующего задания или задания не в статусе dead
+	ErrJobNotRetryable = errors.New("job not found or not in dead state")
+)
+
+// ClaimJob забирает из очереди одно готовое к выполнению задание.
+// Параллельные обработчики не получают одно и то же задание (FOR UPDATE SKIP LOCKED)
+func (r Repository) ClaimJob(ctx context.Context, lease time.Duration) (model.Job, error) {
+	var job model.Job
+
+	err := r.db.QueryRow(ctx, queries.ClaimJob, pgx.NamedArgs{
+		"lease": lease.Seconds(),
+	}).Scan(&job.ID, &job.SongID, &job.Attempts, &job.MaxAttempts, &job.Refresh)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return job, ErrNoJobs
+	}
+	if err != nil {
+		return job, err
+	}
+
+	err = r.db.QueryRow(ctx, queries.SelectJobSong, pgx.NamedArgs{
+		"id": job.SongID,
+	}).Scan(&job.Group, &job.Song)
+	if err != nil {
+		return job, fmt.Errorf("failed to get song of job %d: %w", job.ID, err)
+	}
+
+	return job, nil
+}
+
+// CompleteJob сохраняет полученные данные песни и отмечает задание выполненным
+func (r Repository) CompleteJob(ctx context.Context, job model.Job, song model.Song) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var prevLink, link string
+	if err := tx.QueryRow(ctx, queries.CompleteEnrichment, completeEnrichmentArgs(job.SongID, song)).Scan(&prevLink, &link); err != nil {
+		return err
+	}
+
+	if err := replaceLink(ctx, tx, job.SongID, prevLink, link); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, queries.CompleteJob, pgx.NamedArgs{
+		"id": job.ID,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RescheduleJob возвращает задание в очередь с задержкой
+func (r Repository) RescheduleJob(ctx context.Context, job model.Job, delay time.Duration, cause error) error {
+	_, err := r.db.Exec(ctx, queries.RescheduleJob, pgx.NamedArgs{
+		"id":         job.ID,
+		"delay":      delay.Seconds(),
+		"last_error": cause.Error(),
+	})
+	return err
+}
+
+// BuryJob переводит задание в статус dead, после чего оно выполняется только после ручного перезапуска
+func (r Repository) BuryJob(ctx context.Context, job model.Job, cause error) error {
+	_, err := r.db.Exec(ctx, queries.BuryJob, pgx.NamedArgs{
+		"id":         job.ID,
+		"last_error": cause.Error(),
+	})
+	return err
+}
+
+// EnqueueAll ставит в очередь задания на обновление данных всех песен. Задания запрашивают
+// внешний сервис в обход кэша, иначе в течение времени жизни кэша данные бы не обновились
+func (r Repository) EnqueueAll(ctx context.Context, maxAttempts int) (int64, error) {
+	ct, err := r.db.Exec(ctx, queries.EnqueueAllJobs, pgx.NamedArgs{
+		"max_attempts": maxAttempts,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// EnqueueSongs ставит в очередь задания на обновление в обход кэша данных всех песен,
+// подходящих под фильтр (лимит и страница фильтра не учитываются). Возвращает количество заданий
+func (r Repository) EnqueueSongs(ctx context.Context, filter *model.Filter, maxAttempts int) (int64, error) {
+	query, args := filterSongs(filter)
+	args = append(args, maxAttempts)
+
+	ct, err := r.db.Exec(ctx, fmt.Sprintf(queries.EnqueueFilteredJobs, query, len(args)), args...)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// GetJobs возвращает задания с указанным статусом (все, если статус пустой) с пагинацией
+func (r Repository) GetJobs(ctx context.Context, status string, limit, offset int) ([]model.Job, error) {
+	rows, err := r.db.Query(ctx, queries.SelectJobs, pgx.NamedArgs{
+		"status": status,
+		"limit":  limit,
+		"offset": offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Job, error) {
+		var j model.Job
+		err := row.Scan(&j.ID, &j.SongID, &j.Group, &j.Song, &j.Status, &j.Attempts, &j.MaxAttempts,
+			&j.RunAt, &j.LastError, &j.Refresh, &j.CreatedAt, &j.UpdatedAt)
+		return j, err
+	})
+}
+
+// RetryJob перезапускает задание в статусе dead
+func (r Repository) RetryJob(ctx context.Context, id int64) error {
+	ct, err := r.db.Exec(ctx, queries.RetryJob, pgx.NamedArgs{
+		"id": id,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ct.RowsAffected() == 0 {
+		r.log.Sugar.Debugw("job not retried", "id", id)
+		return ErrJobNotRetryable
+	}
+
+	return nil
+}
+
+// RetryDeadJobs перезапускает все задания в статусе dead
+func (r Repository) RetryDeadJobs(ctx context.Context) (int64, error) {
+	ct, err := r.db.Exec(ctx, queries.RetryDeadJobs)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// completeEnrichmentArgs параметры запроса сохранения данных песни, полученных из внешнего сервиса
+func completeEnrichmentArgs(id int, song model.Song) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"id":                id,
+		"release_date":      song.ReleaseDate.NilIfZero(),
+		"release_precision": precisionNilIfZero(song.ReleaseDate),
+		"lyrics":            song.Text,
+		"link":              song.Link,
+		"lang":              song.Lang,
+		"explicit":          song.Explicit,
+		"explicit_terms":    song.ExplicitTerms,
+		"detail_sources":    sourcesOrEmpty(song.Sources),
+		"payload_hash":      song.PayloadHash,
+		"release_field":     providers.FieldReleaseDate,
+		"text_field":        providers.FieldText,
+		"link_field":        providers.FieldLink,
+		"source_ranks":      providers.SourceRanks,
+	}
+}
+
+// sourcesOrEmpty заменяет отсутствующие источники пустым набором для слияния в БД
+func sourcesOrEmpty(sources map[string]string) map[string]string {
+	if sources == nil {
+		return map[string]string{}
+	}
+	return sources
+}
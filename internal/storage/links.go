@@ -0,0 +1,278 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/plasmatrip/muslib/internal/links"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+)
+
+// ErrLinkNotFound возвращается при удалении ссылки, которой нет у песни
+var ErrLinkNotFound = errors.New("link not found")
+
+// AddLink добавляет песне ссылку, проверенную links.Parse. Для существующей ссылки меняется тип
+func (r Repository) AddLink(ctx context.Context, songID int, link model.Link) error {
+	return addLink(ctx, r.db, queries.AddLink, songID, link)
+}
+
+// DeleteLink удаляет ссылку песни
+func (r Repository) DeleteLink(ctx context.Context, songID int, url string) error {
+	ct, err := r.db.Exec(ctx, queries.DeleteLink, pgx.NamedArgs{
+		"song_id": songID,
+		"url":     url,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ct.RowsAffected() == 0 {
+		return ErrLinkNotFound
+	}
+
+	return nil
+}
+
+// FillLinks добавляет в ссылки песен основные ссылки, сохраненные до появления типов ссылок
+func (r Repository) FillLinks(ctx context.Context) error {
+	rows, err := r.db.Query(ctx, queries.SelectUnsyncedLinks)
+	if err != nil {
+		return fmt.Errorf("failed to select songs without typed links: %w", err)
+	}
+
+	type song struct {
+		id   int
+		link string
+	}
+
+	songs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (song, error) {
+		var s song
+		err := row.Scan(&s.id, &s.link)
+		return s, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read songs without typed links: %w", err)
+	}
+
+	batch := &pgx.Batch{}
+	for _, s := range songs {
+		queueSyncLink(batch, s.id, s.link)
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to fill typed links: %w", err)
+	}
+
+	r.log.Sugar.Debugw("typed links filled", "count", batch.Len())
+
+	return nil
+}
+
+// syncLink добавляет основную ссылку песни в ее ссылки с типом.
+// Ссылки, которые не удалось разобрать, остаются только в поле link
+func syncLink(ctx context.Context, q querier, songID int, raw string) error {
+	if raw == "" {
+		return nil
+	}
+	link, err := links.Parse(raw, "")
+	if err != nil {
+		return nil
+	}
+	return addLink(ctx, q, queries.SyncLink, songID, link)
+}
+
+// queueSyncLink добавляет в пакет запрос syncLink
+func queueSyncLink(batch *pgx.Batch, songID int, raw string) {
+	if raw == "" {
+		return
+	}
+	if link, err := links.Parse(raw, ""); err == nil {
+		batch.Queue(queries.SyncLink, linkArgs(songID, link))
+	}
+}
+
+// replaceLink удаляет из ссылок с типами прежнюю основную ссылку песни, если основная ссылка
+// изменилась, и добавляет новую. Прежняя ссылка удаляется, даже если ее тип задан вручную
+func replaceLink(ctx context.Context, q querier, songID int, prevRaw, raw string) error {
+	if url, ok := staleLink(prevRaw, raw); ok {
+		if _, err := q.Exec(ctx, queries.DeleteLink, pgx.NamedArgs{
+			"song_id": songID,
+			"url":     url,
+		}); err != nil {
+			return err
+		}
+	}
+	return syncLink(ctx, q, songID, raw)
+}
+
+// queueReplaceLink добавляет в пакет запросы replaceLink
+func queueReplaceLink(batch *pgx.Batch, songID int, prevRaw, raw string) {
+	if url, ok := staleLink(prevRaw, raw); ok {
+		batch.Queue(queries.DeleteLink, pgx.NamedArgs{
+			"song_id": songID,
+			"url":     url,
+		})
+	}
+	queueSyncLink(batch, songID, raw)
+}
+
+// staleLink возвращает прежнюю основную ссылку в каноническом виде, если ее заменила новая.
+// Пустая новая ссылка основную ссылку не меняет
+func staleLink(prevRaw, raw string) (string, bool) {
+	if strings.TrimSpace(raw) == "" || prevRaw == raw {
+		return "", false
+	}
+	prev, err := links.Parse(prevRaw, "")
+	if err != nil {
+		return "", false
+	}
+	if link, err := links.Parse(raw, ""); err == nil && link.URL == prev.URL {
+		return "", false
+	}
+	return prev.URL, true
+}
+
+// addLink выполняет запрос добавления ссылки в пуле соединений или транзакции
+func addLink(ctx context.Context, q querier, query string, songID int, link model.Link) error {
+	_, err := q.Exec(ctx, query, linkArgs(songID, link))
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrSongNotFound
+	}
+
+	return err
+}
+
+// linkArgs параметры запросов добавления ссылки
+func linkArgs(songID int, link model.Link) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"song_id":  songID,
+		"url":      link.URL,
+		"kind":     link.Kind,
+		"host":     link.Host,
+		"media_id": link.MediaID,
+	}
+}
+
+// loadLinks заполняет ссылки песен одним запросом
+func loadLinks(ctx context.Context, q querier, songs []model.Song) error {
+	if len(songs) == 0 {
+		return nil
+	}
+
+	index := make(map[int]int, len(songs))
+	ids := make([]int, 0, len(songs))
+	for i, s := range songs {
+		index[s.ID] = i
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := q.Query(ctx, queries.SelectLinks, pgx.NamedArgs{"ids": ids})
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var songID int
+		var link model.Link
+		if err := rows.Scan(&songID, &link.URL, &link.Kind, &link.Host, &link.MediaID, &link.LastChecked, &link.Status,
+			&link.CheckError, &link.RedirectURL, &link.Broken); err != nil {
+			return err
+		}
+		if i, ok := index[songID]; ok {
+			songs[i].Links = append(songs[i].Links, link)
+		}
+	}
+
+	return rows.Err()
+}
+
+// ClaimLinks выбирает до limit ссылок, которые не проверялись дольше maxAge
+func (r Repository) ClaimLinks(ctx context.Context, maxAge time.Duration, limit int) ([]model.LinkCheck, error) {
+	rows, err := r.db.Query(ctx, queries.ClaimLinks, pgx.NamedArgs{
+		"max_age": maxAge.Seconds(),
+		"limit":   limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.LinkCheck, error) {
+		var c model.LinkCheck
+		err := row.Scan(&c.SongID, &c.URL)
+		return c, err
+	})
+}
+
+// SaveLinkCheck сохраняет результат проверки ссылки. Ссылка отмечается недоступной
+// после threshold неудачных проверок подряд
+func (r Repository) SaveLinkCheck(ctx context.Context, check model.LinkCheck, threshold int) error {
+	_, err := r.db.Exec(ctx, queries.SaveLinkCheck, pgx.NamedArgs{
+		"song_id":      check.SongID,
+		"url":          check.URL,
+		"outcome":      check.Outcome,
+		"status":       check.Status,
+		"error":        check.Error,
+		"redirect_url": check.RedirectURL,
+		"threshold":    threshold,
+	})
+	return err
+}
+
+// GetLinkReport возвращает сводку проверки ссылок и недоступные ссылки с пагинацией
+func (r Repository) GetLinkReport(ctx context.Context, limit, offset int) (model.LinkReport, error) {
+	report := model.LinkReport{ByStatus: map[string]int{}, BrokenLinks: []model.BrokenLink{}}
+
+	err := r.db.QueryRow(ctx, queries.LinkReportTotals).Scan(&report.Total, &report.Checked, &report.Broken, &report.Redirected)
+	if err != nil {
+		return report, err
+	}
+
+	rows, err := r.db.Query(ctx, queries.LinkReportStatuses)
+	if err != nil {
+		return report, err
+	}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			rows.Close()
+			return report, err
+		}
+		report.ByStatus[status] = count
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	rows, err = r.db.Query(ctx, queries.SelectBrokenLinks, pgx.NamedArgs{
+		"limit":  limit,
+		"offset": offset,
+	})
+	if err != nil {
+		return report, err
+	}
+	broken, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.BrokenLink, error) {
+		var b model.BrokenLink
+		err := row.Scan(&b.SongID, &b.Group, &b.Song, &b.URL, &b.Kind, &b.Host, &b.MediaID, &b.LastChecked,
+			&b.Status, &b.CheckError, &b.RedirectURL, &b.Broken, &b.Failures)
+		return b, err
+	})
+	if err != nil {
+		return report, err
+	}
+	report.BrokenLinks = append(report.BrokenLinks, broken...)
+
+	return report, nil
+}
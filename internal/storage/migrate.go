@@ -0,0 +1,294 @@
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+)
+
+// uniqueNamesVersion версия миграции, добавляющей ограничение уникальности названий песен без учета регистра
+const uniqueNamesVersion = 5
+
+// latestVersion целевая версия при применении всех миграций
+const latestVersion = ^uint(0)
+
+// ErrNameConflicts возвращается, если миграцию с ограничением уникальности названий
+// нельзя применить из-за песен с совпадающими после нормализации названиями
+var ErrNameConflicts = errors.New("songs with conflicting names must be merged or renamed before migrating")
+
+//go:embed migrations/*.sql
+var migrationsDir embed.FS
+
+// migrationFile имя файла миграции: версия_название.up.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.up\.sql$`)
+
+// Migration встроенная миграция схемы
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+// NameConflict песни, названия которых совпадают после нормализации
+type NameConflict struct {
+	Group string // нормализованное название группы
+	Song  string // нормализованное название песни
+	Songs []model.Song
+}
+
+// MigrateLogger получает сообщения о применяемых миграциях
+type MigrateLogger interface {
+	Printf(format string, v ...interface{})
+}
+
+// StartMigration запускает миграцию
+func StartMigration(ctx context.Context, dsn string) error {
+	m, err := newMigrate(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := checkNameConflicts(ctx, dsn, m, latestVersion); err != nil {
+		return err
+	}
+
+	if err := m.Up(); err != nil {
+		if !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to apply migrations to the DB: %w", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// CheckSchema проверяет, что к БД применены все встроенные миграции
+func CheckSchema(dsn string) error {
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	latest := migrations[len(migrations)-1].Version
+
+	version, dirty, err := SchemaVersion(dsn)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d, fix it and run `muslib migrate force`", ErrDirtySchema, version)
+	}
+	if version < latest {
+		return fmt.Errorf("database schema version %d is older than required %d, run `muslib migrate up`", version, latest)
+	}
+	return nil
+}
+
+// Migrations возвращает встроенные миграции по возрастанию версии
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationsDir, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []Migration
+	for _, e := range entries {
+		match := migrationFile.FindStringSubmatch(e.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s: %w", e.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: uint(version), Name: strings.ReplaceAll(match[2], "_", " ")})
+	}
+	if len(migrations) == 0 {
+		return nil, errors.New("no migrations found")
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// SchemaVersion возвращает версию схемы БД и признак незавершенной миграции.
+// Для БД без миграций возвращается 0
+func SchemaVersion(dsn string) (uint, bool, error) {
+	m, err := newMigrate(dsn)
+	if err != nil {
+		return 0, false, err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// MigrateUp применяет все неприменные миграции
+func MigrateUp(ctx context.Context, dsn string, log MigrateLogger) error {
+	return withMigrate(ctx, dsn, latestVersion, log, func(m *migrate.Migrate) error {
+		return m.Up()
+	})
+}
+
+// MigrateDown откатывает n последних примененных миграций
+func MigrateDown(ctx context.Context, dsn string, n int, log MigrateLogger) error {
+	return withMigrate(ctx, dsn, 0, log, func(m *migrate.Migrate) error {
+		return m.Steps(-n)
+	})
+}
+
+// MigrateTo приводит схему БД к версии version, применяя миграции вверх или вниз
+func MigrateTo(ctx context.Context, dsn string, version uint, log MigrateLogger) error {
+	return withMigrate(ctx, dsn, version, log, func(m *migrate.Migrate) error {
+		return m.Migrate(version)
+	})
+}
+
+// NameConflicts возвращает песни, из-за которых нельзя применить ограничение уникальности названий.
+// Для БД без таблицы песен возвращает пустой список
+func NameConflicts(ctx context.Context, dsn string) ([]NameConflict, error) {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close(ctx)
+
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('music_library') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	rows, err := conn.Query(ctx, queries.SelectNameConflicts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []NameConflict
+	for rows.Next() {
+		var (
+			song        model.Song
+			group, name string
+		)
+		if err := rows.Scan(&song.ID, &song.Group, &song.Song, &group, &name); err != nil {
+			return nil, err
+		}
+		if n := len(conflicts); n == 0 || conflicts[n-1].Group != group || conflicts[n-1].Song != name {
+			conflicts = append(conflicts, NameConflict{Group: group, Song: name})
+		}
+		last := &conflicts[len(conflicts)-1]
+		last.Songs = append(last.Songs, song)
+	}
+
+	return conflicts, rows.Err()
+}
+
+// checkNameConflicts отказывается мигрировать до версии target, если при этом будет применена
+// миграция с ограничением уникальности названий, а в БД есть конфликтующие песни.
+// Так миграция не прерывается посередине и схема не остается незавершенной
+func checkNameConflicts(ctx context.Context, dsn string, m *migrate.Migrate, target uint) error {
+	if target < uniqueNamesVersion {
+		return nil
+	}
+
+	version, _, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+	if err == nil && version >= uniqueNamesVersion {
+		return nil
+	}
+
+	conflicts, err := NameConflicts(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("failed to check song names before migrating: %w", err)
+	}
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	songs := 0
+	for _, c := range conflicts {
+		songs += len(c.Songs)
+	}
+	return fmt.Errorf("%w: %d groups of %d songs, run `muslib migrate conflicts` to list them", ErrNameConflicts, len(conflicts), songs)
+}
+
+// ForceVersion записывает версию схемы и снимает признак незавершенной миграции,
+// не выполняя миграций. Версия -1 означает БД без миграций
+func ForceVersion(ctx context.Context, dsn string, version int) error {
+	return withMigrate(ctx, dsn, 0, nil, func(m *migrate.Migrate) error {
+		return m.Force(version)
+	})
+}
+
+// withMigrate выполняет действие с экземпляром golang-migrate, переводящее схему не выше версии target.
+// Отсутствие изменений не считается ошибкой
+func withMigrate(ctx context.Context, dsn string, target uint, log MigrateLogger, fn func(m *migrate.Migrate) error) error {
+	m, err := newMigrate(dsn)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := checkNameConflicts(ctx, dsn, m, target); err != nil {
+		return err
+	}
+
+	if log != nil {
+		m.Log = migrateLog{log}
+	}
+
+	if err := fn(m); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		var dirty migrate.ErrDirty
+		if errors.As(err, &dirty) {
+			return fmt.Errorf("%w at version %d, fix it and run `muslib migrate force`", ErrDirtySchema, dirty.Version)
+		}
+		return err
+	}
+	return nil
+}
+
+// migrateLog передает сообщения golang-migrate в MigrateLogger
+type migrateLog struct {
+	log MigrateLogger
+}
+
+func (l migrateLog) Printf(format string, v ...interface{}) {
+	l.log.Printf(strings.TrimSuffix(format, "\n"), v...)
+}
+
+func (l migrateLog) Verbose() bool {
+	return false
+}
+
+// newMigrate создает экземпляр golang-migrate со встроенными миграциями
+func newMigrate(dsn string) (*migrate.Migrate, error) {
+	d, err := iofs.New(migrationsDir, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to return an iofs driver: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", d, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a new migrate instance: %w", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_music_library_lang;
+
+ALTER TABLE music_library DROP COLUMN IF EXISTS lang;
+
+COMMIT;
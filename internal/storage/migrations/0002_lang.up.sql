@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS lang varchar(8);
+
+CREATE INDEX IF NOT EXISTS idx_music_library_lang ON music_library (lang);
+
+COMMIT;
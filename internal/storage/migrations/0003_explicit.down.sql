@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_music_library_explicit;
+
+ALTER TABLE music_library DROP COLUMN IF EXISTS explicit_override;
+ALTER TABLE music_library DROP COLUMN IF EXISTS explicit_terms;
+ALTER TABLE music_library DROP COLUMN IF EXISTS explicit;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS explicit boolean NOT NULL DEFAULT false;
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS explicit_terms jsonb;
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS explicit_override boolean;
+
+CREATE INDEX IF NOT EXISTS idx_music_library_explicit ON music_library ((COALESCE(explicit_override, explicit)));
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_music_library_song_key;
+DROP INDEX IF EXISTS idx_music_library_group_key;
+
+ALTER TABLE music_library DROP COLUMN IF EXISTS song_key;
+ALTER TABLE music_library DROP COLUMN IF EXISTS group_key;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS group_key varchar(512);
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS song_key varchar(512);
+
+CREATE INDEX IF NOT EXISTS idx_music_library_group_key ON music_library (group_key);
+CREATE INDEX IF NOT EXISTS idx_music_library_song_key ON music_library (song_key);
+
+COMMIT;
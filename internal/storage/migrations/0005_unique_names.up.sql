@@ -0,0 +1,12 @@
+BEGIN;
+
+-- Конфликты названий, которые после нормализации (NFC, схлопывание пробелов, регистр)
+-- нарушили бы ограничение, проверяются до запуска миграции (muslib migrate conflicts):
+-- при их наличии muslib отказывается применять эту миграцию
+UPDATE music_library
+SET group_name = regexp_replace(btrim(normalize(group_name, NFC)), '\s+', ' ', 'g'),
+    song_name = regexp_replace(btrim(normalize(song_name, NFC)), '\s+', ' ', 'g');
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_music_library_names_ci ON music_library (lower(group_name), lower(song_name));
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE music_library DROP CONSTRAINT IF EXISTS chk_music_library_release_precision;
+
+ALTER TABLE music_library DROP COLUMN IF EXISTS release_precision;
+
+COMMIT;
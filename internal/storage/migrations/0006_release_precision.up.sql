@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS release_precision varchar(5) NOT NULL DEFAULT 'day';
+
+ALTER TABLE music_library ADD CONSTRAINT chk_music_library_release_precision
+    CHECK (release_precision IN ('year', 'month', 'day'));
+
+COMMIT;
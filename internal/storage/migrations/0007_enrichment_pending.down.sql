@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_music_library_enrichment_pending;
+
+ALTER TABLE music_library DROP COLUMN IF EXISTS enrichment_pending;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS enrichment_pending boolean NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_music_library_enrichment_pending ON music_library (id) WHERE enrichment_pending;
+
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    id bigserial NOT NULL,
+    song_id integer NOT NULL REFERENCES music_library (id) ON DELETE CASCADE,
+    status varchar(16) NOT NULL DEFAULT 'pending',
+    attempts integer NOT NULL DEFAULT 0,
+    max_attempts integer NOT NULL,
+    run_at timestamptz NOT NULL DEFAULT now(),
+    last_error text,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (id),
+    CONSTRAINT chk_enrichment_jobs_status CHECK (status IN ('pending', 'running', 'done', 'dead'))
+);
+
+-- у песни может быть только одно активное задание
+CREATE UNIQUE INDEX IF NOT EXISTS idx_enrichment_jobs_active_song ON enrichment_jobs (song_id) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_run_at ON enrichment_jobs (run_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_enrichment_jobs_status ON enrichment_jobs (status);
+
+-- песни, сохраненные при недоступности внешнего сервиса, переносим в очередь
+INSERT INTO enrichment_jobs (song_id, max_attempts)
+SELECT id, 5 FROM music_library WHERE enrichment_pending;
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS info_cache (
+    key text NOT NULL,
+    payload jsonb,
+    not_found boolean NOT NULL DEFAULT false,
+    expires_at timestamptz NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_info_cache_expires_at ON info_cache (expires_at);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE music_library DROP COLUMN IF EXISTS detail_sources;
+
+COMMIT;
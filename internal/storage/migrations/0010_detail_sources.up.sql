@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS detail_sources jsonb;
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP INDEX IF EXISTS music_library_enriched_at_idx;
+
+ALTER TABLE music_library DROP COLUMN IF EXISTS payload_hash;
+ALTER TABLE music_library DROP COLUMN IF EXISTS enriched_at;
+
+COMMIT;
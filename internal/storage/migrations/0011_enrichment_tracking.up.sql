@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS enriched_at timestamptz;
+ALTER TABLE music_library ADD COLUMN IF NOT EXISTS payload_hash text;
+
+CREATE INDEX IF NOT EXISTS music_library_enriched_at_idx ON music_library (enriched_at);
+
+COMMIT;
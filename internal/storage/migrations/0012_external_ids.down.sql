@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS import_progress;
+DROP TABLE IF EXISTS song_external_ids;
+
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+
+-- внешние идентификаторы песен (MusicBrainz ID и т.п.), значение уникально в пределах схемы
+CREATE TABLE IF NOT EXISTS song_external_ids (
+    song_id integer NOT NULL REFERENCES music_library (id) ON DELETE CASCADE,
+    scheme varchar(32) NOT NULL,
+    value varchar(255) NOT NULL,
+    PRIMARY KEY (scheme, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_song_external_ids_song ON song_external_ids (song_id);
+
+-- позиция, до которой обработан файл импорта, для продолжения после остановки
+CREATE TABLE IF NOT EXISTS import_progress (
+    name text NOT NULL,
+    fingerprint text NOT NULL,
+    position bigint NOT NULL,
+    updated_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (name)
+);
+
+COMMIT;
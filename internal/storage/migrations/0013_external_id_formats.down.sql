@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE song_external_ids DROP CONSTRAINT IF EXISTS song_external_ids_format;
+ALTER TABLE song_external_ids DROP COLUMN IF EXISTS created_at;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE song_external_ids ADD COLUMN IF NOT EXISTS created_at timestamptz NOT NULL DEFAULT now();
+
+-- значения хранятся в каноническом виде, формат проверяется и в приложении
+ALTER TABLE song_external_ids ADD CONSTRAINT song_external_ids_format CHECK (
+    CASE scheme
+        WHEN 'isrc' THEN value ~ '^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$'
+        WHEN 'mbid' THEN value ~ '^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$'
+        WHEN 'spotify' THEN value ~ '^[0-9A-Za-z]{22}$'
+        WHEN 'youtube' THEN value ~ '^[0-9A-Za-z_-]{11}$'
+        WHEN 'apple_music' THEN value ~ '^[1-9][0-9]{0,19}$'
+        WHEN 'deezer' THEN value ~ '^[1-9][0-9]{0,19}$'
+        ELSE false
+    END
+);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- ссылки песни с типами; основная ссылка music_library.link тоже попадает сюда
+CREATE TABLE IF NOT EXISTS song_links (
+    song_id integer NOT NULL REFERENCES music_library (id) ON DELETE CASCADE,
+    url varchar(2048) NOT NULL,
+    kind varchar(16) NOT NULL CHECK (kind IN ('video', 'audio', 'lyrics', 'purchase', 'other')),
+    host varchar(255) NOT NULL,
+    media_id varchar(64),
+    created_at timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_song_links_kind ON song_links (kind, song_id);
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_song_links_broken;
+DROP INDEX IF EXISTS idx_song_links_last_checked;
+
+ALTER TABLE song_links
+    DROP COLUMN IF EXISTS broken,
+    DROP COLUMN IF EXISTS failures,
+    DROP COLUMN IF EXISTS redirect_url,
+    DROP COLUMN IF EXISTS check_error,
+    DROP COLUMN IF EXISTS check_status,
+    DROP COLUMN IF EXISTS last_checked;
+
+COMMIT;
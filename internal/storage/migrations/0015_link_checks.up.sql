@@ -0,0 +1,15 @@
+BEGIN;
+
+-- результат последней проверки доступности ссылки
+ALTER TABLE song_links
+    ADD COLUMN IF NOT EXISTS last_checked timestamptz,
+    ADD COLUMN IF NOT EXISTS check_status integer,
+    ADD COLUMN IF NOT EXISTS check_error text,
+    ADD COLUMN IF NOT EXISTS redirect_url varchar(2048),
+    ADD COLUMN IF NOT EXISTS failures integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS broken boolean NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_song_links_last_checked ON song_links (last_checked NULLS FIRST);
+CREATE INDEX IF NOT EXISTS idx_song_links_broken ON song_links (song_id) WHERE broken;
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_music_library_song_name_trgm;
+DROP INDEX IF EXISTS idx_music_library_group_name_trgm;
+DROP INDEX IF EXISTS idx_music_library_song_key_trgm;
+DROP INDEX IF EXISTS idx_music_library_group_key_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_music_library_group_key ON music_library (group_key);
+CREATE INDEX IF NOT EXISTS idx_music_library_song_key ON music_library (song_key);
+
+-- расширение pg_trgm не удаляется: его могут использовать другие схемы БД
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+-- поиск по подстроке (ILIKE/LIKE '%...%') не использует B-tree индексы,
+-- поэтому ключи и названия индексируются триграммами
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+DROP INDEX IF EXISTS idx_music_library_group_key;
+DROP INDEX IF EXISTS idx_music_library_song_key;
+
+CREATE INDEX IF NOT EXISTS idx_music_library_group_key_trgm ON music_library USING gin (group_key gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_music_library_song_key_trgm ON music_library USING gin (song_key gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_music_library_group_name_trgm ON music_library USING gin (group_name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_music_library_song_name_trgm ON music_library USING gin (song_name gin_trgm_ops);
+
+COMMIT;
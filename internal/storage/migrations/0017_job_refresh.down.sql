@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE enrichment_jobs DROP COLUMN IF EXISTS refresh;
+
+COMMIT;
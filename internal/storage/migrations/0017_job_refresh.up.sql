@@ -0,0 +1,6 @@
+BEGIN;
+
+-- задания повторного получения данных (команда reenrich) запрашивают внешний сервис в обход кэша
+ALTER TABLE enrichment_jobs ADD COLUMN IF NOT EXISTS refresh boolean NOT NULL DEFAULT false;
+
+COMMIT;
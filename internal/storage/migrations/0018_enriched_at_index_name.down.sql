@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER INDEX IF EXISTS idx_music_library_enriched_at RENAME TO music_library_enriched_at_idx;
+
+COMMIT;
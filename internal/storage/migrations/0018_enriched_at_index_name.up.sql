@@ -0,0 +1,6 @@
+BEGIN;
+
+-- индекс называется так же, как остальные индексы схемы
+ALTER INDEX IF EXISTS music_library_enriched_at_idx RENAME TO idx_music_library_enriched_at;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS playlist_songs;
+DROP TABLE IF EXISTS playlists;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+-- сохраненные плейлисты
+CREATE TABLE IF NOT EXISTS playlists (
+    id serial PRIMARY KEY,
+    title varchar(255) NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+-- песни плейлиста по порядку; удаленная из библиотеки песня пропадает из плейлистов
+CREATE TABLE IF NOT EXISTS playlist_songs (
+    playlist_id integer NOT NULL REFERENCES playlists (id) ON DELETE CASCADE,
+    position integer NOT NULL,
+    song_id integer NOT NULL REFERENCES music_library (id) ON DELETE CASCADE,
+    PRIMARY KEY (playlist_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_playlist_songs_song_id ON playlist_songs (song_id);
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- длинные основные ссылки остаются только в song_links
+UPDATE music_library SET link = '' WHERE length(link) > 255;
+
+ALTER TABLE music_library ALTER COLUMN link TYPE varchar(255);
+
+COMMIT;
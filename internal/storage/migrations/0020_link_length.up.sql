@@ -0,0 +1,6 @@
+BEGIN;
+
+-- основная ссылка может быть такой же длины, как ссылки в song_links
+ALTER TABLE music_library ALTER COLUMN link TYPE varchar(2048);
+
+COMMIT;
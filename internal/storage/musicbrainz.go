@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/providers"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+	"github.com/plasmatrip/muslib/internal/translit"
+)
+
+// GetImportProgress возвращает сохраненную позицию импорта. Если импорт с таким
+// названием не выполнялся, возвращается нулевая позиция
+func (r Repository) GetImportProgress(ctx context.Context, name string) (model.ImportProgress, error) {
+	progress := model.ImportProgress{Name: name}
+
+	err := r.db.QueryRow(ctx, queries.SelectImportProgress, pgx.NamedArgs{
+		"name": name,
+	}).Scan(&progress.Fingerprint, &progress.Position)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return progress, nil
+	}
+
+	return progress, err
+}
+
+// ImportMusicBrainz сохраняет песни из MusicBrainz с их MBID и в той же транзакции
+// запоминает позицию импорта, поэтому после остановки импорт продолжается без пропусков и повторов.
+// source - название источника даты релиза в detail_sources
+func (r Repository) ImportMusicBrainz(ctx context.Context, songs []model.Song, source string, progress model.ImportProgress) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	batch := &pgx.Batch{}
+	for _, song := range songs {
+		sources := map[string]string{}
+		if !song.ReleaseDate.Time.IsZero() {
+			sources[providers.FieldReleaseDate] = source
+		}
+
+		for _, id := range song.ExternalIDs {
+			batch.Queue(queries.UpsertMusicBrainzSong, pgx.NamedArgs{
+				"group_name":        song.Group,
+				"song_name":         song.Song,
+				"group_key":         translit.Key(song.Group),
+				"song_key":          translit.Key(song.Song),
+				"release_date":      song.ReleaseDate.Time,
+				"release_precision": string(song.ReleaseDate.PrecisionOrDefault()),
+				"release_set":       !song.ReleaseDate.Time.IsZero(),
+				"detail_sources":    sources,
+				"source":            source,
+				"field":             providers.FieldReleaseDate,
+				"source_ranks":      providers.SourceRanks,
+				"scheme":            id.Scheme,
+				"value":             id.Value,
+			})
+		}
+	}
+
+	batch.Queue(queries.SaveImportProgress, pgx.NamedArgs{
+		"name":        progress.Name,
+		"fingerprint": progress.Fingerprint,
+		"position":    progress.Position,
+	})
+
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
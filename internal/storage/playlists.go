@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/storage/queries"
+)
+
+// ErrPlaylistNotFound возвращается, если плейлиста с заданным идентификатором нет
+var ErrPlaylistNotFound = errors.New("playlist not found")
+
+// CreatePlaylist сохраняет плейлист и возвращает его идентификатор.
+// Если какой-либо песни нет в библиотеке, возвращается ErrSongNotFound
+func (r Repository) CreatePlaylist(ctx context.Context, p model.Playlist) (int, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	if err := tx.QueryRow(ctx, queries.InsertPlaylist, pgx.NamedArgs{"title": p.Title}).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	if err := insertPlaylistSongs(ctx, tx, id, p.SongIDs); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit(ctx)
+}
+
+// UpdatePlaylist заменяет название и песни плейлиста
+func (r Repository) UpdatePlaylist(ctx context.Context, p model.Playlist) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	ct, err := tx.Exec(ctx, queries.UpdatePlaylist, pgx.NamedArgs{
+		"id":    p.ID,
+		"title": p.Title,
+	})
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrPlaylistNotFound
+	}
+
+	if _, err := tx.Exec(ctx, queries.DeletePlaylistSongs, pgx.NamedArgs{"id": p.ID}); err != nil {
+		return err
+	}
+	if err := insertPlaylistSongs(ctx, tx, p.ID, p.SongIDs); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// insertPlaylistSongs сохраняет песни плейлиста по порядку
+func insertPlaylistSongs(ctx context.Context, q querier, id int, songIDs []int) error {
+	if len(songIDs) == 0 {
+		return nil
+	}
+
+	_, err := q.Exec(ctx, queries.InsertPlaylistSongs, pgx.NamedArgs{
+		"id":       id,
+		"song_ids": songIDs,
+	})
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrSongNotFound
+	}
+
+	return err
+}
+
+// DeletePlaylist удаляет плейлист. Песни остаются в библиотеке
+func (r Repository) DeletePlaylist(ctx context.Context, id int) error {
+	ct, err := r.db.Exec(ctx, queries.DeletePlaylist, pgx.NamedArgs{"id": id})
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrPlaylistNotFound
+	}
+	return nil
+}
+
+// GetPlaylists возвращает плейлисты с пагинацией
+func (r Repository) GetPlaylists(ctx context.Context, limit, offset int) ([]model.Playlist, error) {
+	return r.selectPlaylists(ctx, nil, limit, offset)
+}
+
+// GetPlaylist возвращает плейлист по идентификатору
+func (r Repository) GetPlaylist(ctx context.Context, id int) (model.Playlist, error) {
+	playlists, err := r.selectPlaylists(ctx, &id, 1, 0)
+	if err != nil {
+		return model.Playlist{}, err
+	}
+	if len(playlists) == 0 {
+		return model.Playlist{}, ErrPlaylistNotFound
+	}
+	return playlists[0], nil
+}
+
+func (r Repository) selectPlaylists(ctx context.Context, id *int, limit, offset int) ([]model.Playlist, error) {
+	rows, err := r.db.Query(ctx, queries.SelectPlaylists, pgx.NamedArgs{
+		"id":     id,
+		"limit":  limit,
+		"offset": offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Playlist, error) {
+		var p model.Playlist
+		err := row.Scan(&p.ID, &p.Title, &p.SongIDs, &p.CreatedAt, &p.UpdatedAt)
+		return p, err
+	})
+}
+
+// PlaylistSongs возвращает песни плейлиста в порядке плейлиста
+func (r Repository) PlaylistSongs(ctx context.Context, p model.Playlist) ([]model.Song, error) {
+	if len(p.SongIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.Query(ctx, queries.SelectSongs+` AND id = ANY($1)`, p.SongIDs)
+	if err != nil {
+		return nil, err
+	}
+	found, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Song, error) {
+		return scanSong(row)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := loadExternalIDs(ctx, r.db, found); err != nil {
+		return nil, err
+	}
+	if err := loadLinks(ctx, r.db, found); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int]model.Song, len(found))
+	for _, s := range found {
+		byID[s.ID] = s
+	}
+
+	songs := make([]model.Song, 0, len(p.SongIDs))
+	for _, id := range p.SongIDs {
+		if s, ok := byID[id]; ok {
+			songs = append(songs, s)
+		}
+	}
+	return songs, nil
+}
@@ -2,33 +2,500 @@ package queries
 
 const (
 	AddSong = `
-		INSERT INTO music_library (group_name, song_name, release_date, lyrics, link)
-		VALUES (@group_name, @song_name, @release_date, @lyrics, @link);
+		INSERT INTO music_library (group_name, song_name, group_key, song_key, release_date, release_precision, lyrics, link, lang, explicit, explicit_terms, enrichment_pending)
+		VALUES (@group_name, @song_name, @group_key, @song_key, @release_date, @release_precision, @lyrics, @link, NULLIF(@lang, ''), @explicit, @explicit_terms, @enrichment_pending)
+		RETURNING id;
 	`
+	// ImportSong добавляет песню, если ее нет в библиотеке, и при необходимости ставит
+	// задание на получение данных. Для существующей песни строк не возвращает
+	ImportSong = `
+		WITH ins AS (
+			INSERT INTO music_library (group_name, song_name, group_key, song_key, release_date, release_precision, lyrics, link, lang, explicit, explicit_terms, enrichment_pending, detail_sources)
+			VALUES (@group_name, @song_name, @group_key, @song_key, @release_date, @release_precision, @lyrics, @link, NULLIF(@lang, ''), @explicit, @explicit_terms, @enrich, @detail_sources)
+			ON CONFLICT DO NOTHING
+			RETURNING id
+		), job AS (
+			INSERT INTO enrichment_jobs (song_id, max_attempts)
+			SELECT id, @max_attempts FROM ins WHERE @enrich
+			ON CONFLICT (song_id) WHERE status IN ('pending', 'running') DO NOTHING
+		)
+		SELECT id, true, '' FROM ins;
+	`
+
+	// UpsertSong добавляет песню или обновляет заданные поля существующей и возвращает
+	// прежнюю основную ссылку. Дата релиза не заменяется датой из источника с более низким
+	// приоритетом (@source_ranks). Задание на получение данных ставится только для добавленных песен
+	UpsertSong = `
+		WITH prev AS (
+			SELECT link,
+				COALESCE((@source_ranks::jsonb->>(detail_sources->>@release_field::text))::int, 0)
+					<= COALESCE((@source_ranks::jsonb->>(@detail_sources::jsonb->>@release_field::text))::int, 0) AS release_replaced
+			FROM music_library
+			WHERE lower(group_name) = lower(@group_name) AND lower(song_name) = lower(@song_name)
+		), up AS (
+			INSERT INTO music_library (group_name, song_name, group_key, song_key, release_date, release_precision, lyrics, link, lang, explicit, explicit_terms, enrichment_pending, detail_sources)
+			VALUES (@group_name, @song_name, @group_key, @song_key, @release_date, @release_precision, @lyrics, @link, NULLIF(@lang, ''), @explicit, @explicit_terms, @enrich, @detail_sources)
+			ON CONFLICT ((lower(group_name)), (lower(song_name))) DO UPDATE
+			SET release_date = CASE WHEN @release_set AND (SELECT release_replaced FROM prev) THEN EXCLUDED.release_date ELSE music_library.release_date END,
+				release_precision = CASE WHEN @release_set AND (SELECT release_replaced FROM prev) THEN EXCLUDED.release_precision ELSE music_library.release_precision END,
+				lyrics = CASE WHEN TRIM(EXCLUDED.lyrics) != '' THEN EXCLUDED.lyrics ELSE music_library.lyrics END,
+				link = CASE WHEN TRIM(EXCLUDED.link) != '' THEN EXCLUDED.link ELSE music_library.link END,
+				lang = CASE WHEN TRIM(EXCLUDED.lyrics) != '' THEN EXCLUDED.lang ELSE music_library.lang END,
+				explicit = CASE WHEN TRIM(EXCLUDED.lyrics) != '' THEN EXCLUDED.explicit ELSE music_library.explicit END,
+				explicit_terms = CASE WHEN TRIM(EXCLUDED.lyrics) != '' THEN EXCLUDED.explicit_terms ELSE music_library.explicit_terms END,
+				detail_sources = COALESCE(music_library.detail_sources, '{}'::jsonb) || CASE
+					WHEN (SELECT release_replaced FROM prev) THEN EXCLUDED.detail_sources
+					ELSE EXCLUDED.detail_sources - @release_field::text
+				END
+			RETURNING id, (xmax = 0) AS inserted
+		), job AS (
+			INSERT INTO enrichment_jobs (song_id, max_attempts)
+			SELECT id, @max_attempts FROM up WHERE inserted AND @enrich
+			ON CONFLICT (song_id) WHERE status IN ('pending', 'running') DO NOTHING
+		)
+		SELECT id, inserted, COALESCE((SELECT link FROM prev), '') FROM up;
+	`
+
+	// UpsertMusicBrainzSong добавляет песню из MusicBrainz или уточняет дату релиза существующей
+	// и сохраняет MBID записи. Дата из MusicBrainz не заменяет дату из источника с более высоким
+	// приоритетом (@source_ranks), например заданную вручную, и заменяет остальные; из нескольких
+	// дат MusicBrainz остается самая ранняя
+	UpsertMusicBrainzSong = `
+		WITH up AS (
+			INSERT INTO music_library (group_name, song_name, group_key, song_key, release_date, release_precision, lyrics, link, enrichment_pending, detail_sources)
+			VALUES (@group_name, @song_name, @group_key, @song_key, @release_date, @release_precision, '', '', false, @detail_sources)
+			ON CONFLICT ((lower(group_name)), (lower(song_name))) DO UPDATE
+			SET release_date = CASE
+					WHEN NOT @release_set THEN music_library.release_date
+					WHEN COALESCE((@source_ranks::jsonb->>(music_library.detail_sources->>@field::text))::int, 0)
+						> COALESCE((@source_ranks::jsonb->>@source::text)::int, 0) THEN music_library.release_date
+					WHEN music_library.detail_sources->>@field::text = @source AND music_library.release_date <= EXCLUDED.release_date THEN music_library.release_date
+					ELSE EXCLUDED.release_date
+				END,
+				release_precision = CASE
+					WHEN NOT @release_set THEN music_library.release_precision
+					WHEN COALESCE((@source_ranks::jsonb->>(music_library.detail_sources->>@field::text))::int, 0)
+						> COALESCE((@source_ranks::jsonb->>@source::text)::int, 0) THEN music_library.release_precision
+					WHEN music_library.detail_sources->>@field::text = @source AND music_library.release_date <= EXCLUDED.release_date THEN music_library.release_precision
+					ELSE EXCLUDED.release_precision
+				END,
+				detail_sources = CASE
+					WHEN NOT @release_set THEN music_library.detail_sources
+					WHEN COALESCE((@source_ranks::jsonb->>(music_library.detail_sources->>@field::text))::int, 0)
+						> COALESCE((@source_ranks::jsonb->>@source::text)::int, 0) THEN music_library.detail_sources
+					ELSE COALESCE(music_library.detail_sources, '{}'::jsonb) || EXCLUDED.detail_sources
+				END
+			RETURNING id
+		)
+		INSERT INTO song_external_ids (song_id, scheme, value)
+		SELECT id, @scheme, @value FROM up
+		ON CONFLICT (scheme, value) DO NOTHING;
+	`
+
+	SelectImportProgress = `
+		SELECT fingerprint, position
+		FROM import_progress
+		WHERE name = @name;
+	`
+
+	SaveImportProgress = `
+		INSERT INTO import_progress (name, fingerprint, position)
+		VALUES (@name, @fingerprint, @position)
+		ON CONFLICT (name) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, position = EXCLUDED.position, updated_at = now();
+	`
+
 	DeleteSong = `
 		DELETE FROM music_library
-		WHERE group_name = @group_name AND song_name = @song_name;
+		WHERE lower(group_name) = lower(@group_name) AND lower(song_name) = lower(@song_name);
 	`
 
+	// UpdateSong обновляет песню и возвращает ее идентификатор и прежнюю основную ссылку
 	UpdateSong = `
-		UPDATE music_library
+		UPDATE music_library m
 		SET group_name = @group_name,
 			song_name = @song_name,
-			release_date = COALESCE(@release_date, release_date),
-			lyrics = CASE WHEN TRIM(@lyrics) != '' THEN @lyrics ELSE lyrics END,
-			link = CASE WHEN TRIM(@link) != '' THEN @link ELSE link END
-		WHERE group_name = @group_name AND song_name = @song_name;
+			group_key = @group_key,
+			song_key = @song_key,
+			release_date = COALESCE(@release_date, m.release_date),
+			release_precision = COALESCE(@release_precision, m.release_precision),
+			lyrics = CASE WHEN TRIM(@lyrics) != '' THEN @lyrics ELSE m.lyrics END,
+			link = CASE WHEN TRIM(@link) != '' THEN @link ELSE m.link END,
+			lang = CASE WHEN TRIM(@lyrics) != '' THEN NULLIF(@lang, '') ELSE m.lang END,
+			explicit = CASE WHEN TRIM(@lyrics) != '' THEN @explicit ELSE m.explicit END,
+			explicit_terms = CASE WHEN TRIM(@lyrics) != '' THEN @explicit_terms ELSE m.explicit_terms END,
+			detail_sources = COALESCE(m.detail_sources, '{}'::jsonb) || @detail_sources
+		FROM (
+			SELECT id, link
+			FROM music_library
+			WHERE lower(group_name) = lower(@group_name) AND lower(song_name) = lower(@song_name)
+			FOR UPDATE
+		) old
+		WHERE m.id = old.id
+		RETURNING m.id, COALESCE(old.link, '');
+	`
+
+	SetExplicitOverride = `
+		UPDATE music_library
+		SET explicit_override = @explicit
+		WHERE lower(group_name) = lower(@group_name) AND lower(song_name) = lower(@song_name);
+	`
+
+	// SelectNameConflicts выбирает песни, названия которых совпадают после нормализации
+	// (NFC, схлопывание пробелов, регистр), упорядоченные по нормализованным названиям
+	SelectNameConflicts = `
+		WITH names AS (
+			SELECT id, group_name, song_name,
+				lower(regexp_replace(btrim(normalize(group_name, NFC)), '\s+', ' ', 'g')) AS group_norm,
+				lower(regexp_replace(btrim(normalize(song_name, NFC)), '\s+', ' ', 'g')) AS song_norm
+			FROM music_library
+		)
+		SELECT id, group_name, song_name, group_norm, song_norm
+		FROM names
+		WHERE (group_norm, song_norm) IN (
+			SELECT group_norm, song_norm FROM names GROUP BY group_norm, song_norm HAVING count(*) > 1
+		)
+		ORDER BY group_norm, song_norm, id;
+	`
+
+	SelectMissingSearchKeys = `
+		SELECT id, group_name, song_name
+		FROM music_library
+		WHERE group_key IS NULL OR song_key IS NULL;
+	`
+
+	UpdateSearchKeys = `
+		UPDATE music_library
+		SET group_key = @group_key, song_key = @song_key
+		WHERE id = @id;
+	`
+
+	// CompleteEnrichment сохраняет полученные данные песни и возвращает прежнюю и новую основные ссылки.
+	// Поле не заменяется, если оно заполнено источником с более высоким приоритетом (@source_ranks),
+	// например задано вручную. Источники сохраняются только для записанных полей
+	CompleteEnrichment = `
+		UPDATE music_library m
+		SET release_date = CASE WHEN old.release_replaced THEN COALESCE(@release_date, m.release_date) ELSE m.release_date END,
+			release_precision = CASE WHEN old.release_replaced THEN COALESCE(@release_precision, m.release_precision) ELSE m.release_precision END,
+			lyrics = CASE WHEN TRIM(@lyrics) != '' AND old.text_replaced THEN @lyrics ELSE m.lyrics END,
+			link = CASE WHEN TRIM(@link) != '' AND old.link_replaced THEN @link ELSE m.link END,
+			lang = CASE WHEN TRIM(@lyrics) != '' AND old.text_replaced THEN NULLIF(@lang, '') ELSE m.lang END,
+			explicit = CASE WHEN TRIM(@lyrics) != '' AND old.text_replaced THEN @explicit ELSE m.explicit END,
+			explicit_terms = CASE WHEN TRIM(@lyrics) != '' AND old.text_replaced THEN @explicit_terms ELSE m.explicit_terms END,
+			detail_sources = COALESCE(m.detail_sources, '{}'::jsonb) || COALESCE((
+				SELECT jsonb_object_agg(s.key, s.value)
+				FROM jsonb_each_text(@detail_sources::jsonb) s
+				WHERE COALESCE((@source_ranks::jsonb->>(m.detail_sources->>s.key))::int, 0)
+					<= COALESCE((@source_ranks::jsonb->>s.value)::int, 0)
+			), '{}'::jsonb),
+			enrichment_pending = false,
+			enriched_at = now(),
+			payload_hash = @payload_hash
+		FROM (
+			SELECT id, link,
+				COALESCE((@source_ranks::jsonb->>(detail_sources->>@release_field::text))::int, 0)
+					<= COALESCE((@source_ranks::jsonb->>(@detail_sources::jsonb->>@release_field::text))::int, 0) AS release_replaced,
+				COALESCE((@source_ranks::jsonb->>(detail_sources->>@text_field::text))::int, 0)
+					<= COALESCE((@source_ranks::jsonb->>(@detail_sources::jsonb->>@text_field::text))::int, 0) AS text_replaced,
+				COALESCE((@source_ranks::jsonb->>(detail_sources->>@link_field::text))::int, 0)
+					<= COALESCE((@source_ranks::jsonb->>(@detail_sources::jsonb->>@link_field::text))::int, 0) AS link_replaced
+			FROM music_library
+			WHERE id = @id
+			FOR UPDATE
+		) old
+		WHERE m.id = old.id
+		RETURNING COALESCE(old.link, ''), COALESCE(m.link, '');
+	`
+
+	EnqueueJob = `
+		INSERT INTO enrichment_jobs (song_id, max_attempts)
+		VALUES (@song_id, @max_attempts)
+		ON CONFLICT (song_id) WHERE status IN ('pending', 'running') DO NOTHING;
+	`
+
+	// EnqueueAllJobs ставит задания повторного получения данных в обход кэша.
+	// Уже стоящие в очереди задания песен тоже переводятся в обход кэша
+	EnqueueAllJobs = `
+		INSERT INTO enrichment_jobs (song_id, max_attempts, refresh)
+		SELECT id, @max_attempts, true FROM music_library
+		ON CONFLICT (song_id) WHERE status IN ('pending', 'running') DO UPDATE SET refresh = true;
+	`
+
+	// SelectFilteredSongIDs выбирает идентификаторы песен, выбранных подзапросом фильтра.
+	// Шаблон для fmt.Sprintf: %s - подзапрос
+	SelectFilteredSongIDs = `
+		SELECT id FROM (%s) AS songs ORDER BY id;
+	`
+
+	// EnqueueFilteredJobs то же, что EnqueueAllJobs, для песен, выбранных подзапросом фильтра.
+	// Шаблон для fmt.Sprintf: %[1]s - подзапрос, %[2]d - номер параметра max_attempts
+	EnqueueFilteredJobs = `
+		INSERT INTO enrichment_jobs (song_id, max_attempts, refresh)
+		SELECT id, $%[2]d, true FROM (%[1]s) AS songs
+		ON CONFLICT (song_id) WHERE status IN ('pending', 'running') DO UPDATE SET refresh = true;
+	`
+
+	// ClaimJob забирает одно готовое к выполнению задание. Задания, зависшие в статусе
+	// running дольше @lease (например, после падения сервера), забираются повторно
+	ClaimJob = `
+		UPDATE enrichment_jobs
+		SET status = 'running', attempts = attempts + 1, updated_at = now()
+		WHERE id = (
+			SELECT id FROM enrichment_jobs
+			WHERE (status = 'pending' AND run_at <= now())
+				OR (status = 'running' AND updated_at < now() - make_interval(secs => @lease))
+			ORDER BY run_at
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING id, song_id, attempts, max_attempts, refresh;
+	`
+
+	SelectJobSong = `
+		SELECT group_name, song_name
+		FROM music_library
+		WHERE id = @id;
+	`
+
+	CompleteJob = `
+		UPDATE enrichment_jobs
+		SET status = 'done', last_error = NULL, updated_at = now()
+		WHERE id = @id;
+	`
+
+	RescheduleJob = `
+		UPDATE enrichment_jobs
+		SET status = 'pending', run_at = now() + make_interval(secs => @delay), last_error = @last_error, updated_at = now()
+		WHERE id = @id;
+	`
+
+	BuryJob = `
+		UPDATE enrichment_jobs
+		SET status = 'dead', last_error = @last_error, updated_at = now()
+		WHERE id = @id;
+	`
+
+	SelectJobs = `
+		SELECT j.id, j.song_id, m.group_name, m.song_name, j.status, j.attempts, j.max_attempts,
+			j.run_at, COALESCE(j.last_error, ''), j.refresh, j.created_at, j.updated_at
+		FROM enrichment_jobs j
+		JOIN music_library m ON m.id = j.song_id
+		WHERE (@status = '' OR j.status = @status)
+		ORDER BY j.id DESC
+		LIMIT @limit OFFSET @offset;
+	`
+
+	RetryJob = `
+		UPDATE enrichment_jobs
+		SET status = 'pending', attempts = 0, run_at = now(), updated_at = now()
+		WHERE id = @id AND status = 'dead'
+			AND NOT EXISTS (
+				SELECT 1 FROM enrichment_jobs a
+				WHERE a.song_id = enrichment_jobs.song_id AND a.status IN ('pending', 'running')
+			);
+	`
+
+	RetryDeadJobs = `
+		UPDATE enrichment_jobs j
+		SET status = 'pending', attempts = 0, run_at = now(), updated_at = now()
+		WHERE j.status = 'dead'
+			AND j.id = (SELECT max(d.id) FROM enrichment_jobs d WHERE d.song_id = j.song_id AND d.status = 'dead')
+			AND NOT EXISTS (
+				SELECT 1 FROM enrichment_jobs a
+				WHERE a.song_id = j.song_id AND a.status IN ('pending', 'running')
+			);
+	`
+
+	SelectCachedDetail = `
+		SELECT payload, not_found, expires_at
+		FROM info_cache
+		WHERE key = @key;
+	`
+
+	UpsertCachedDetail = `
+		INSERT INTO info_cache (key, payload, not_found, expires_at)
+		VALUES (@key, @payload, @not_found, @expires_at)
+		ON CONFLICT (key) DO UPDATE
+		SET payload = EXCLUDED.payload, not_found = EXCLUDED.not_found,
+			expires_at = EXCLUDED.expires_at, updated_at = now();
+	`
+
+	DeleteExpiredCache = `
+		DELETE FROM info_cache
+		WHERE expires_at < now();
 	`
 
 	SelectSongs = `
-		SELECT group_name, song_name, release_date, lyrics, link
+		SELECT id, group_name, song_name, release_date, release_precision, lyrics, link, COALESCE(lang, ''),
+			COALESCE(explicit_override, explicit), explicit_terms, enrichment_pending, detail_sources,
+			enriched_at, COALESCE(payload_hash, '')
 		FROM music_library
 		WHERE 1=1
 	`
 
+	SelectExternalIDs = `
+		SELECT song_id, scheme, value
+		FROM song_external_ids
+		WHERE song_id = ANY(@ids)
+		ORDER BY song_id, scheme, value;
+	`
+
+	AddExternalID = `
+		INSERT INTO song_external_ids (song_id, scheme, value)
+		VALUES (@song_id, @scheme, @value)
+		ON CONFLICT (scheme, value) DO UPDATE
+		SET song_id = song_external_ids.song_id
+		WHERE song_external_ids.song_id = EXCLUDED.song_id
+		RETURNING song_id;
+	`
+
+	DeleteExternalID = `
+		DELETE FROM song_external_ids
+		WHERE song_id = @song_id AND scheme = @scheme AND value = @value;
+	`
+
+	SelectLinks = `
+		SELECT song_id, url, kind, host, COALESCE(media_id, ''), last_checked, COALESCE(check_status, 0),
+			COALESCE(check_error, ''), COALESCE(redirect_url, ''), broken
+		FROM song_links
+		WHERE song_id = ANY(@ids)
+		ORDER BY song_id, created_at, url;
+	`
+
+	// AddLink добавляет ссылку или меняет тип существующей
+	AddLink = `
+		INSERT INTO song_links (song_id, url, kind, host, media_id)
+		VALUES (@song_id, @url, @kind, @host, NULLIF(@media_id, ''))
+		ON CONFLICT (song_id, url) DO UPDATE
+		SET kind = EXCLUDED.kind, host = EXCLUDED.host, media_id = EXCLUDED.media_id;
+	`
+
+	// SyncLink добавляет основную ссылку песни, не меняя тип, заданный вручную
+	SyncLink = `
+		INSERT INTO song_links (song_id, url, kind, host, media_id)
+		VALUES (@song_id, @url, @kind, @host, NULLIF(@media_id, ''))
+		ON CONFLICT (song_id, url) DO NOTHING;
+	`
+
+	DeleteLink = `
+		DELETE FROM song_links
+		WHERE song_id = @song_id AND url = @url;
+	`
+
+	// ClaimLinks выбирает ссылки для проверки и отмечает их проверенными,
+	// чтобы параллельные проверки не получили те же ссылки
+	ClaimLinks = `
+		UPDATE song_links
+		SET last_checked = now()
+		WHERE (song_id, url) IN (
+			SELECT song_id, url FROM song_links
+			WHERE last_checked IS NULL OR last_checked < now() - make_interval(secs => @max_age)
+			ORDER BY last_checked NULLS FIRST
+			FOR UPDATE SKIP LOCKED
+			LIMIT @limit
+		)
+		RETURNING song_id, url;
+	`
+
+	// SaveLinkCheck сохраняет результат проверки. Ссылка считается недоступной
+	// после @threshold неудачных проверок подряд, неопределенный результат счетчик не меняет
+	SaveLinkCheck = `
+		UPDATE song_links
+		SET last_checked = now(),
+			check_status = NULLIF(@status, 0),
+			check_error = NULLIF(@error, ''),
+			redirect_url = NULLIF(@redirect_url, ''),
+			failures = CASE @outcome WHEN 'ok' THEN 0 WHEN 'failed' THEN failures + 1 ELSE failures END,
+			broken = CASE @outcome WHEN 'ok' THEN false WHEN 'failed' THEN failures + 1 >= @threshold ELSE broken END
+		WHERE song_id = @song_id AND url = @url;
+	`
+
+	LinkReportTotals = `
+		SELECT count(*), count(last_checked), count(*) FILTER (WHERE broken), count(redirect_url)
+		FROM song_links;
+	`
+
+	LinkReportStatuses = `
+		SELECT COALESCE(check_status::text, 'error'), count(*)
+		FROM song_links
+		WHERE last_checked IS NOT NULL AND (check_status IS NOT NULL OR check_error IS NOT NULL)
+		GROUP BY 1;
+	`
+
+	SelectBrokenLinks = `
+		SELECT l.song_id, m.group_name, m.song_name, l.url, l.kind, l.host, COALESCE(l.media_id, ''), l.last_checked,
+			COALESCE(l.check_status, 0), COALESCE(l.check_error, ''), COALESCE(l.redirect_url, ''), l.broken, l.failures
+		FROM song_links l
+		JOIN music_library m ON m.id = l.song_id
+		WHERE l.broken
+		ORDER BY l.song_id, l.url
+		LIMIT @limit OFFSET @offset;
+	`
+
+	SelectUnsyncedLinks = `
+		SELECT id, link
+		FROM music_library m
+		WHERE TRIM(link) != '' AND NOT EXISTS (SELECT 1 FROM song_links l WHERE l.song_id = m.id);
+	`
+
 	SelectSong = `
 		SELECT lyrics
 		FROM music_library
-		WHERE group_name = @group AND song_name = @song;
+		WHERE lower(group_name) = lower(@group) AND lower(song_name) = lower(@song);
+	`
+
+	InsertPlaylist = `
+		INSERT INTO playlists (title)
+		VALUES (@title)
+		RETURNING id;
+	`
+
+	UpdatePlaylist = `
+		UPDATE playlists
+		SET title = @title, updated_at = now()
+		WHERE id = @id;
+	`
+
+	DeletePlaylist = `
+		DELETE FROM playlists
+		WHERE id = @id;
+	`
+
+	// InsertPlaylistSongs сохраняет песни плейлиста в порядке массива song_ids
+	InsertPlaylistSongs = `
+		INSERT INTO playlist_songs (playlist_id, position, song_id)
+		SELECT @id, s.position::integer, s.song_id
+		FROM unnest(@song_ids::integer[]) WITH ORDINALITY AS s (song_id, position);
+	`
+
+	DeletePlaylistSongs = `
+		DELETE FROM playlist_songs
+		WHERE playlist_id = @id;
+	`
+
+	SelectPlaylists = `
+		SELECT p.id, p.title, COALESCE(array_agg(s.song_id ORDER BY s.position) FILTER (WHERE s.song_id IS NOT NULL), '{}'),
+			p.created_at, p.updated_at
+		FROM playlists p
+		LEFT JOIN playlist_songs s ON s.playlist_id = p.id
+		WHERE @id::integer IS NULL OR p.id = @id
+		GROUP BY p.id
+		ORDER BY p.id
+		LIMIT @limit OFFSET @offset;
+	`
+
+	// SelectSongTexts выбирает тексты песен по порядку идентификаторов, начиная после @after
+	SelectSongTexts = `
+		SELECT id, lyrics
+		FROM music_library
+		WHERE id > @after
+		ORDER BY id
+		LIMIT @limit;
+	`
+
+	UpdateTextAnalysis = `
+		UPDATE music_library
+		SET lang = NULLIF(@lang, ''), explicit = @explicit, explicit_terms = @explicit_terms
+		WHERE id = @id;
 	`
 )
@@ -2,7 +2,6 @@ package storage
 
 import (
 	"context"
-	"embed"
 	"errors"
 	"fmt"
 	"strconv"
@@ -11,12 +10,24 @@ import (
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/plasmatrip/muslib/internal/logger"
 	"github.com/plasmatrip/muslib/internal/model"
+	"github.com/plasmatrip/muslib/internal/providers"
 	"github.com/plasmatrip/muslib/internal/storage/queries"
+	"github.com/plasmatrip/muslib/internal/translit"
+)
+
+// uniqueViolation код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
+
+var (
+	// ErrSongExists возвращается при добавлении песни, которая уже есть в библиотеке
+	ErrSongExists = errors.New("song already exists")
+	// ErrSongNotFound возвращается, если песни с указанным идентификатором нет
+	ErrSongNotFound = errors.New("song not found")
 )
 
 type Repository struct {
@@ -24,20 +35,48 @@ type Repository struct {
 	log logger.Logger
 }
 
-func NewRepository(ctx context.Context, dsn string, log logger.Logger) (*Repository, error) {
-	// запускаем миграцию
-	err := StartMigration(dsn)
-	if err != nil {
-		if !errors.Is(err, migrate.ErrNoChange) {
-			return nil, err
+// NewRepository подключается к БД. Если autoMigrate равен true, схема БД обновляется
+// до последней версии, иначе проверяется, что миграции уже применены
+func NewRepository(ctx context.Context, dsn string, autoMigrate bool, log logger.Logger) (*Repository, error) {
+	if autoMigrate {
+		// запускаем миграцию
+		err := StartMigration(ctx, dsn)
+		if err != nil {
+			if !errors.Is(err, migrate.ErrNoChange) {
+				return nil, err
+			} else {
+				log.Sugar.Debugw("the database exists, there is nothing to migrate")
+			}
 		} else {
-			log.Sugar.Debugw("the database exists, there is nothing to migrate")
+			log.Sugar.Debugw("database migration was successful")
 		}
-	} else {
-		log.Sugar.Debugw("database migration was successful")
+	} else if err := CheckSchema(dsn); err != nil {
+		return nil, err
+	}
+
+	repo, err := OpenRepository(ctx, dsn, log)
+	if err != nil {
+		return nil, err
 	}
 
-	// открываем БД
+	// заполняем поисковые ключи для песен, добавленных до их появления
+	if err := repo.FillSearchKeys(ctx); err != nil {
+		repo.Close()
+		return nil, err
+	}
+
+	// переносим основные ссылки песен, добавленных до появления типов ссылок
+	if err := repo.FillLinks(ctx); err != nil {
+		repo.Close()
+		return nil, err
+	}
+
+	return repo, nil
+}
+
+// OpenRepository открывает БД без миграции и проверки схемы. Используется командами,
+// которые сами управляют схемой, например восстановлением из резервной копии
+func OpenRepository(ctx context.Context, dsn string, log logger.Logger) (*Repository, error) {
 	db, err := pgxpool.New(ctx, dsn)
 	if err != nil {
 		return nil, err
@@ -49,26 +88,45 @@ func NewRepository(ctx context.Context, dsn string, log logger.Logger) (*Reposit
 	}, nil
 }
 
-//go:embed migrations/*.sql
-var migrationsDir embed.FS
-
-// StartMigration запускает миграцию
-func StartMigration(dsn string) error {
-	d, err := iofs.New(migrationsDir, "migrations")
+// FillSearchKeys вычисляет транслитерированные поисковые ключи для песен, у которых их нет
+func (r Repository) FillSearchKeys(ctx context.Context) error {
+	rows, err := r.db.Query(ctx, queries.SelectMissingSearchKeys)
 	if err != nil {
-		return fmt.Errorf("failed to return an iofs driver: %w", err)
+		return fmt.Errorf("failed to select songs without search keys: %w", err)
+	}
+
+	type song struct {
+		id          int
+		group, name string
 	}
 
-	m, err := migrate.NewWithSourceInstance("iofs", d, dsn)
+	songs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (song, error) {
+		var s song
+		err := row.Scan(&s.id, &s.group, &s.name)
+		return s, err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get a new migrate instance: %w", err)
+		return fmt.Errorf("failed to read songs without search keys: %w", err)
 	}
-	if err := m.Up(); err != nil {
-		if !errors.Is(err, migrate.ErrNoChange) {
-			return fmt.Errorf("failed to apply migrations to the DB: %w", err)
-		}
-		return err
+
+	if len(songs) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for _, s := range songs {
+		batch.Queue(queries.UpdateSearchKeys, pgx.NamedArgs{
+			"id":        s.id,
+			"group_key": translit.Key(s.group),
+			"song_key":  translit.Key(s.name),
+		})
 	}
+	if err := r.db.SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to update search keys: %w", err)
+	}
+
+	r.log.Sugar.Debugw("search keys filled", "count", len(songs))
+
 	return nil
 }
 
@@ -84,23 +142,83 @@ func (r Repository) Close() {
 
 // AddSong добавляет песню
 func (r Repository) AddSong(ctx context.Context, song model.Song) error {
-	ct, err := r.db.Exec(ctx, queries.AddSong, pgx.NamedArgs{
-		"group_name":   song.Group,
-		"song_name":    song.Song,
-		"release_date": time.Time(song.ReleaseDate),
-		"lyrics":       song.Text,
-		"link":         song.Link,
-	})
+	_, err := r.addSong(ctx, r.db, song)
+	return err
+}
+
+// AddSongAndEnqueue добавляет песню и в той же транзакции ставит задание
+// на получение данных из внешнего сервиса
+func (r Repository) AddSongAndEnqueue(ctx context.Context, song model.Song, maxAttempts int) error {
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	if ct.RowsAffected() == 0 {
-		r.log.Sugar.Debugw("song not added", "group", song.Group, "song", song.Song)
-		return errors.New("song not added")
+	song.EnrichmentPending = true
+	id, err := r.addSong(ctx, tx, song)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	for _, extID := range song.ExternalIDs {
+		if err := addExternalID(ctx, tx, id, extID); err != nil {
+			return err
+		}
+	}
+
+	if err := syncLink(ctx, tx, id, song.Link); err != nil {
+		return err
+	}
+	for _, link := range song.Links {
+		if err := addLink(ctx, tx, queries.AddLink, id, link); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(ctx, queries.EnqueueJob, pgx.NamedArgs{
+		"song_id":      id,
+		"max_attempts": maxAttempts,
+	}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// querier общий интерфейс пула соединений и транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// addSong добавляет песню и возвращает ее идентификатор
+func (r Repository) addSong(ctx context.Context, q querier, song model.Song) (int, error) {
+	var id int
+	err := q.QueryRow(ctx, queries.AddSong, pgx.NamedArgs{
+		"group_name":         song.Group,
+		"song_name":          song.Song,
+		"group_key":          translit.Key(song.Group),
+		"song_key":           translit.Key(song.Song),
+		"release_date":       song.ReleaseDate.Time,
+		"release_precision":  string(song.ReleaseDate.PrecisionOrDefault()),
+		"lyrics":             song.Text,
+		"link":               song.Link,
+		"lang":               song.Lang,
+		"explicit":           song.Explicit,
+		"explicit_terms":     song.ExplicitTerms,
+		"enrichment_pending": song.EnrichmentPending,
+	}).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return 0, ErrSongExists
+		}
+		return 0, err
+	}
+
+	return id, nil
 }
 
 // DeleteSong удаляет песню
@@ -123,20 +241,57 @@ func (r Repository) DeleteSong(ctx context.Context, song model.Song) error {
 
 // UpdateSong обновляет песню
 func (r Repository) UpdateSong(ctx context.Context, song model.Song) error {
-	ct, err := r.db.Exec(ctx, queries.UpdateSong, pgx.NamedArgs{
-		"group_name":   song.Group,
-		"song_name":    song.Song,
-		"release_date": song.ReleaseDate.NilIfZero(),
-		"lyrics":       song.Text,
-		"link":         song.Link,
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	var prevLink string
+	err = tx.QueryRow(ctx, queries.UpdateSong, pgx.NamedArgs{
+		"group_name":        song.Group,
+		"song_name":         song.Song,
+		"group_key":         translit.Key(song.Group),
+		"song_key":          translit.Key(song.Song),
+		"release_date":      song.ReleaseDate.NilIfZero(),
+		"release_precision": precisionNilIfZero(song.ReleaseDate),
+		"lyrics":            song.Text,
+		"link":              song.Link,
+		"lang":              song.Lang,
+		"explicit":          song.Explicit,
+		"explicit_terms":    song.ExplicitTerms,
+		"detail_sources":    manualSources(song),
+	}).Scan(&id, &prevLink)
+	if errors.Is(err, pgx.ErrNoRows) {
+		r.log.Sugar.Debugw("song not updated", "group", song.Group, "song", song.Song, "release_date", song.ReleaseDate, "lyrics", song.Text, "link", song.Link)
+		return errors.New("song not updated")
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := replaceLink(ctx, tx, id, prevLink, song.Link); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetExplicitOverride устанавливает или снимает ручной признак ненормативной лексики
+func (r Repository) SetExplicitOverride(ctx context.Context, override model.ExplicitOverride) error {
+	ct, err := r.db.Exec(ctx, queries.SetExplicitOverride, pgx.NamedArgs{
+		"group_name": override.Group,
+		"song_name":  override.Song,
+		"explicit":   override.Explicit,
 	})
 	if err != nil {
 		return err
 	}
 
 	if ct.RowsAffected() == 0 {
-		r.log.Sugar.Debugw("song not updated", "group", song.Group, "song", song.Song, "release_date", song.ReleaseDate, "lyrics", song.Text, "link", song.Link)
-		return errors.New("song not updated")
+		r.log.Sugar.Debugw("explicit override not set", "group", override.Group, "song", override.Song)
+		return ErrSongNotFound
 	}
 
 	return nil
@@ -144,20 +299,80 @@ func (r Repository) UpdateSong(ctx context.Context, song model.Song) error {
 
 // GetSongs возвращает список песен по фильтру с пагинацией
 func (r Repository) GetSongs(ctx context.Context, filter *model.Filter) ([]model.Song, error) {
+	query, args := filterSongs(filter)
+	argID := len(args) + 1
+
+	query += ` ORDER BY release_date LIMIT $` + strconv.Itoa(argID)
+	args = append(args, filter.Limit)
+	argID++
+	query += ` OFFSET $` + strconv.Itoa(argID)
+	args = append(args, filter.Page)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var songs []model.Song
+	for rows.Next() {
+		s, err := scanSong(rows)
+		if err != nil {
+			return nil, err
+		}
+		songs = append(songs, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := loadExternalIDs(ctx, r.db, songs); err != nil {
+		return nil, err
+	}
+	if err := loadLinks(ctx, r.db, songs); err != nil {
+		return nil, err
+	}
+
+	return songs, nil
+}
+
+// GetSongIDs возвращает идентификаторы всех песен, подходящих под фильтр (лимит и страница
+// фильтра не учитываются), в порядке добавления
+func (r Repository) GetSongIDs(ctx context.Context, filter *model.Filter) ([]int, error) {
+	query, args := filterSongs(filter)
+
+	rows, err := r.db.Query(ctx, fmt.Sprintf(queries.SelectFilteredSongIDs, query), args...)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[int])
+}
+
+// nameCondition добавляет к запросу поиск по подстроке названия или его ключа транслитерации.
+// Если ключ пустой (в значении нет букв и цифр), по ключу не ищем: пустой шаблон совпал бы с любой песней
+func nameCondition(query string, args []interface{}, argID int, column, keyColumn, value string) (string, []interface{}, int) {
+	key := translit.Key(value)
+	if key == "" {
+		query += ` AND ` + column + ` ILIKE $` + strconv.Itoa(argID)
+		return query, append(args, "%"+value+"%"), argID + 1
+	}
+
+	query += ` AND (` + column + ` ILIKE $` + strconv.Itoa(argID) + ` OR ` + keyColumn + ` LIKE $` + strconv.Itoa(argID+1) + `)`
+	return query, append(args, "%"+value+"%", "%"+key+"%"), argID + 2
+}
+
+// filterSongs возвращает запрос песен с условиями фильтра (без сортировки и пагинации) и его параметры
+func filterSongs(filter *model.Filter) (string, []interface{}) {
 	args := []interface{}{}
 	argID := 1
 
 	var query = queries.SelectSongs
 
 	if filter.Group != nil {
-		query += ` AND group_name ILIKE $` + strconv.Itoa(argID)
-		args = append(args, "%"+*filter.Group+"%")
-		argID++
+		query, args, argID = nameCondition(query, args, argID, "group_name", "group_key", *filter.Group)
 	}
 	if filter.Song != nil {
-		query += ` AND song_name ILIKE $` + strconv.Itoa(argID)
-		args = append(args, "%"+*filter.Song+"%")
-		argID++
+		query, args, argID = nameCondition(query, args, argID, "song_name", "song_key", *filter.Song)
 	}
 	if filter.ReleaseFrom != nil {
 		query += ` AND release_date >= $` + strconv.Itoa(argID)
@@ -179,32 +394,95 @@ func (r Repository) GetSongs(ctx context.Context, filter *model.Filter) ([]model
 		args = append(args, "%"+*filter.Link+"%")
 		argID++
 	}
+	if filter.Lang != nil {
+		query += ` AND lang = $` + strconv.Itoa(argID)
+		args = append(args, *filter.Lang)
+		argID++
+	}
+	if filter.Explicit != nil {
+		query += ` AND COALESCE(explicit_override, explicit) = $` + strconv.Itoa(argID)
+		args = append(args, *filter.Explicit)
+		argID++
+	}
+	if filter.LinkKind != nil {
+		query += ` AND id IN (SELECT song_id FROM song_links WHERE kind = $` + strconv.Itoa(argID) + `)`
+		args = append(args, *filter.LinkKind)
+		argID++
+	}
+	if filter.BrokenLink != nil {
+		cond := `id IN (SELECT song_id FROM song_links WHERE broken)`
+		if !*filter.BrokenLink {
+			cond = `NOT ` + cond
+		}
+		query += ` AND ` + cond
+	}
+	if filter.ExternalID != nil {
+		query += ` AND id IN (SELECT song_id FROM song_external_ids WHERE scheme = $` + strconv.Itoa(argID) + ` AND value = $` + strconv.Itoa(argID+1) + `)`
+		args = append(args, filter.ExternalID.Scheme, filter.ExternalID.Value)
+		argID += 2
+	}
+	if filter.EnrichedBefore != nil {
+		query += ` AND (enriched_at IS NULL OR enriched_at < $` + strconv.Itoa(argID) + `)`
+		args = append(args, *filter.EnrichedBefore)
+	}
 
-	query += ` ORDER BY release_date LIMIT $` + strconv.Itoa(argID)
-	args = append(args, filter.Limit)
-	argID++
-	query += ` OFFSET $` + strconv.Itoa(argID)
-	args = append(args, filter.Page)
+	return query, args
+}
 
-	rows, err := r.db.Query(ctx, query, args...)
+// GetSong возвращает песню по идентификатору
+func (r Repository) GetSong(ctx context.Context, id int) (model.Song, error) {
+	song, err := scanSong(r.db.QueryRow(ctx, queries.SelectSongs+` AND id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return song, ErrSongNotFound
+	}
 	if err != nil {
-		return nil, err
+		return song, err
 	}
-	defer rows.Close()
 
-	var songs []model.Song
-	for rows.Next() {
-		var s model.Song
-		var rd time.Time
-		err := rows.Scan(&s.Group, &s.Song, &rd, &s.Text, &s.Link)
-		if err != nil {
-			return nil, err
-		}
-		s.ReleaseDate = model.ReleaseDate(rd)
-		songs = append(songs, s)
+	songs := []model.Song{song}
+	if err := loadExternalIDs(ctx, r.db, songs); err != nil {
+		return song, err
 	}
+	err = loadLinks(ctx, r.db, songs)
+	return songs[0], err
+}
 
-	return songs, nil
+// RefreshSong сохраняет повторно полученные из внешнего сервиса данные песни
+func (r Repository) RefreshSong(ctx context.Context, id int, song model.Song) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var prevLink, link string
+	err = tx.QueryRow(ctx, queries.CompleteEnrichment, completeEnrichmentArgs(id, song)).Scan(&prevLink, &link)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrSongNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := replaceLink(ctx, tx, id, prevLink, link); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// scanSong читает песню из строки результата запроса SelectSongs
+func scanSong(row pgx.Row) (model.Song, error) {
+	var s model.Song
+	var rd time.Time
+	var precision string
+	err := row.Scan(&s.ID, &s.Group, &s.Song, &rd, &precision, &s.Text, &s.Link, &s.Lang, &s.Explicit, &s.ExplicitTerms,
+		&s.EnrichmentPending, &s.Sources, &s.EnrichedAt, &s.PayloadHash)
+	if err != nil {
+		return s, err
+	}
+	s.ReleaseDate = model.ReleaseDate{Time: rd, Precision: model.DatePrecision(precision)}
+	return s, nil
 }
 
 // GetLyrics возвращает текст песни с пагинацией по куплетам
@@ -241,3 +519,26 @@ func (r Repository) GetLyrics(ctx context.Context, song model.Song, verseNum int
 
 	return verse, nil
 }
+
+// precisionNilIfZero возвращает точность даты или nil, если дата не задана
+func precisionNilIfZero(rd model.ReleaseDate) interface{} {
+	if rd.Time.IsZero() {
+		return nil
+	}
+	return string(rd.PrecisionOrDefault())
+}
+
+// manualSources отмечает поля, заданные вручную при обновлении песни
+func manualSources(song model.Song) map[string]string {
+	sources := make(map[string]string)
+	if !song.ReleaseDate.Time.IsZero() {
+		sources[providers.FieldReleaseDate] = providers.SourceManual
+	}
+	if strings.TrimSpace(song.Text) != "" {
+		sources[providers.FieldText] = providers.SourceManual
+	}
+	if strings.TrimSpace(song.Link) != "" {
+		sources[providers.FieldLink] = providers.SourceManual
+	}
+	return sources
+}
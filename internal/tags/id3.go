@@ -0,0 +1,360 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+)
+
+// id3v22Frames соответствие идентификаторов кадров ID3v2.2 идентификаторам ID3v2.3
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TYE": "TYER",
+	"TDA": "TDAT",
+	"ULT": "USLT",
+	"SLT": "SYLT",
+}
+
+// readMP3 читает ID3v2 в начале файла и дополняет его данными ID3v1 в конце файла
+func readMP3(f io.ReadSeeker) (Tags, error) {
+	t, _, err := readID3v2(f)
+	if err != nil {
+		return t, err
+	}
+
+	v1, err := readID3v1(f)
+	if err != nil {
+		return t, err
+	}
+	t.merge(v1)
+
+	return t, nil
+}
+
+// readID3v1 читает 128-байтный тег ID3v1 в конце файла
+func readID3v1(f io.ReadSeeker) (Tags, error) {
+	if _, err := f.Seek(-128, io.SeekEnd); err != nil {
+		// файл короче тега
+		return Tags{}, nil
+	}
+
+	buf := make([]byte, 128)
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return Tags{}, err
+	}
+	if string(buf[:3]) != "TAG" {
+		return Tags{}, nil
+	}
+
+	field := func(b []byte) string {
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+		return strings.TrimSpace(latin1(b))
+	}
+
+	return Tags{
+		Title:  field(buf[3:33]),
+		Artist: field(buf[33:63]),
+		Date:   field(buf[93:97]),
+	}, nil
+}
+
+// readID3v2 читает тег ID3v2 версий 2.2-2.4 с текущей позиции.
+// Возвращает false, если тега нет
+func readID3v2(r io.ReadSeeker) (Tags, bool, error) {
+	var t Tags
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(r, header); err != nil {
+		r.Seek(0, io.SeekStart)
+		return t, false, nil
+	}
+	if string(header[:3]) != "ID3" {
+		_, err := r.Seek(0, io.SeekStart)
+		return t, false, err
+	}
+
+	major, flags := header[3], header[5]
+	size := syncsafe(header[6:10])
+	if size > maxTagSize {
+		return t, true, fmt.Errorf("ID3v2 tag is too large: %d bytes", size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return t, true, fmt.Errorf("failed to read ID3v2 tag: %w", err)
+	}
+
+	// в v2.4 за тегом может следовать 10-байтный футер
+	if major == 4 && flags&0x10 != 0 {
+		if _, err := r.Seek(10, io.SeekCurrent); err != nil {
+			return t, true, err
+		}
+	}
+
+	// v2.2 со сжатием не имеет описанного алгоритма, неизвестные версии не разбираем
+	if major < 2 || major > 4 || (major == 2 && flags&0x40 != 0) {
+		return t, true, nil
+	}
+
+	// в v2.2 и v2.3 рассинхронизация применяется ко всему тегу, в v2.4 - к каждому кадру
+	if flags&0x80 != 0 && major < 4 {
+		body = unsync(body)
+	}
+
+	// пропускаем расширенный заголовок
+	if flags&0x40 != 0 && major >= 3 && len(body) >= 4 {
+		ext := int(binary.BigEndian.Uint32(body[:4])) + 4
+		if major == 4 {
+			ext = int(syncsafe(body[:4]))
+		}
+		if ext > len(body) {
+			return t, true, errors.New("invalid ID3v2 extended header")
+		}
+		body = body[ext:]
+	}
+
+	var year, date, recorded string
+
+	for len(body) > 0 {
+		id, data, rest, ok := nextFrame(body, major, flags&0x80 != 0)
+		if !ok {
+			break
+		}
+		body = rest
+		if data == nil {
+			continue
+		}
+
+		switch id {
+		case "TIT2":
+			t.Title = textFrame(data)
+		case "TPE1":
+			t.Artist = textFrame(data)
+		case "TYER":
+			year = textFrame(data)
+		case "TDAT":
+			date = textFrame(data)
+		case "TDRC":
+			recorded = textFrame(data)
+		case "USLT":
+			if lyrics := lyricsFrame(data); len(lyrics) > len(t.Lyrics) || t.Synced {
+				t.Lyrics, t.Synced = lyrics, false
+			}
+		case "SYLT":
+			if t.Lyrics == "" {
+				t.Lyrics, t.Synced = syncedLyricsFrame(data), true
+			}
+		}
+	}
+
+	t.Date = releaseDate(recorded, year, date)
+
+	return t, true, nil
+}
+
+// nextFrame выделяет очередной кадр. data равен nil для кадров, которые нельзя прочитать
+// (сжатые или зашифрованные). ok равен false, если кадры закончились
+func nextFrame(body []byte, major byte, tagUnsync bool) (id string, data, rest []byte, ok bool) {
+	headerLen := 10
+	if major == 2 {
+		headerLen = 6
+	}
+	if len(body) < headerLen || body[0] == 0 {
+		// начались байты заполнения
+		return "", nil, nil, false
+	}
+
+	var size int
+	var frameFlags byte
+	switch major {
+	case 2:
+		id = string(body[:3])
+		size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		if mapped, ok := id3v22Frames[id]; ok {
+			id = mapped
+		}
+	case 3:
+		id = string(body[:4])
+		size = int(binary.BigEndian.Uint32(body[4:8]))
+		frameFlags = body[9]
+	default:
+		id = string(body[:4])
+		size = int(syncsafe(body[4:8]))
+		frameFlags = body[9]
+	}
+
+	if size < 0 || size > len(body)-headerLen {
+		return "", nil, nil, false
+	}
+	data = body[headerLen : headerLen+size]
+	rest = body[headerLen+size:]
+
+	switch major {
+	case 3:
+		if frameFlags&0xc0 != 0 {
+			return id, nil, rest, true
+		}
+		if frameFlags&0x20 != 0 && len(data) > 0 {
+			data = data[1:]
+		}
+	case 4:
+		if frameFlags&0x0c != 0 {
+			return id, nil, rest, true
+		}
+		if frameFlags&0x40 != 0 && len(data) > 0 {
+			data = data[1:]
+		}
+		if frameFlags&0x01 != 0 && len(data) >= 4 {
+			data = data[4:]
+		}
+		if frameFlags&0x02 != 0 || tagUnsync {
+			data = unsync(data)
+		}
+	}
+
+	return id, data, rest, true
+}
+
+// releaseDate собирает дату релиза из TDRC (v2.4) или TYER и TDAT (v2.3)
+func releaseDate(recorded, year, date string) string {
+	if recorded != "" {
+		// TDRC в формате ISO 8601, время отбрасываем
+		if len(recorded) > 10 {
+			recorded = recorded[:10]
+		}
+		return recorded
+	}
+	if len(year) == 4 && len(date) == 4 {
+		// TDAT в формате DDMM
+		return date[:2] + "-" + date[2:] + "-" + year
+	}
+	return year
+}
+
+// textFrame декодирует текстовый кадр. Из нескольких значений (v2.4) берется первое
+func textFrame(data []byte) string {
+	if len(data) < 1 {
+		return ""
+	}
+	text := decodeText(data[0], data[1:])
+	if i := strings.IndexByte(text, 0); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
+
+// lyricsFrame декодирует кадр USLT: кодировка, язык, описание, текст
+func lyricsFrame(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	enc := data[0]
+	_, text := splitTerminated(enc, data[4:])
+	return normalizeLyrics(decodeText(enc, text))
+}
+
+// syncedLyricsFrame декодирует кадр SYLT и возвращает текст без таймингов,
+// по одной записи на строку
+func syncedLyricsFrame(data []byte) string {
+	if len(data) < 6 {
+		return ""
+	}
+	enc := data[0]
+	_, rest := splitTerminated(enc, data[6:])
+
+	var lines []string
+	for len(rest) > 0 {
+		var text []byte
+		text, rest = splitTerminated(enc, rest)
+		if len(rest) < 4 {
+			rest = nil
+		} else {
+			rest = rest[4:]
+		}
+		if line := strings.TrimSpace(decodeText(enc, text)); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// normalizeLyrics приводит переводы строк к \n
+func normalizeLyrics(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	return strings.TrimSpace(strings.TrimRight(s, "\x00"))
+}
+
+// splitTerminated отделяет строку, завершенную нулем, от остальных данных.
+// Для UTF-16 терминатор - два нулевых байта на четной позиции
+func splitTerminated(enc byte, b []byte) ([]byte, []byte) {
+	if enc == 1 || enc == 2 {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return b[:i], b[i+2:]
+			}
+		}
+		return b, nil
+	}
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return b[:i], b[i+1:]
+	}
+	return b, nil
+}
+
+// decodeText декодирует строку в кодировке ID3v2: 0 - ISO-8859-1,
+// 1 - UTF-16 с BOM, 2 - UTF-16BE, 3 - UTF-8
+func decodeText(enc byte, b []byte) string {
+	switch enc {
+	case 1, 2:
+		bigEndian := enc == 2
+		if len(b) >= 2 {
+			switch {
+			case b[0] == 0xfe && b[1] == 0xff:
+				bigEndian, b = true, b[2:]
+			case b[0] == 0xff && b[1] == 0xfe:
+				bigEndian, b = false, b[2:]
+			}
+		}
+		units := make([]uint16, 0, len(b)/2)
+		for i := 0; i+1 < len(b); i += 2 {
+			if bigEndian {
+				units = append(units, binary.BigEndian.Uint16(b[i:]))
+			} else {
+				units = append(units, binary.LittleEndian.Uint16(b[i:]))
+			}
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	case 3:
+		return strings.TrimRight(string(b), "\x00")
+	default:
+		return strings.TrimRight(latin1(b), "\x00")
+	}
+}
+
+// latin1 декодирует строку ISO-8859-1
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// syncsafe декодирует 28-битное число, записанное по 7 бит в байте
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
+
+// unsync отменяет рассинхронизацию: последовательность 0xFF 0x00 заменяется на 0xFF
+func unsync(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xff, 0x00}, []byte{0xff})
+}
@@ -0,0 +1,301 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+	"unicode/utf16"
+)
+
+// id3Tag собирает тег ID3v2 из заголовка и тела
+func id3Tag(major, flags byte, body []byte) []byte {
+	size := len(body)
+	header := []byte{'I', 'D', '3', major, 0, flags,
+		byte(size >> 21 & 0x7f), byte(size >> 14 & 0x7f), byte(size >> 7 & 0x7f), byte(size & 0x7f)}
+	return append(header, body...)
+}
+
+// id3Frame собирает кадр ID3v2 версии major: в v2.2 - 3-байтные идентификатор и размер,
+// в v2.3 - размер в big-endian, в v2.4 - syncsafe
+func id3Frame(major byte, id string, flags byte, data []byte) []byte {
+	var b []byte
+	switch major {
+	case 2:
+		n := len(data)
+		b = append([]byte(id), byte(n>>16), byte(n>>8), byte(n))
+	case 3:
+		b = binary.BigEndian.AppendUint32([]byte(id), uint32(len(data)))
+		b = append(b, 0, flags)
+	default:
+		n := len(data)
+		b = append([]byte(id), byte(n>>21&0x7f), byte(n>>14&0x7f), byte(n>>7&0x7f), byte(n&0x7f), 0, flags)
+	}
+	return append(b, data...)
+}
+
+// utf16Text кодирует строку в UTF-16 с BOM заданного порядка байтов
+func utf16Text(s string, bigEndian bool) []byte {
+	var b []byte
+	if bigEndian {
+		b = []byte{0xfe, 0xff}
+	} else {
+		b = []byte{0xff, 0xfe}
+	}
+	for _, u := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			b = binary.BigEndian.AppendUint16(b, u)
+		} else {
+			b = binary.LittleEndian.AppendUint16(b, u)
+		}
+	}
+	return b
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func TestSyncsafe(t *testing.T) {
+	tests := []struct {
+		b    []byte
+		want uint32
+	}{
+		{b: []byte{0, 0, 0, 0x7f}, want: 127},
+		{b: []byte{0, 0, 1, 0}, want: 128},
+		{b: []byte{0, 0, 2, 1}, want: 257},
+		{b: []byte{0x7f, 0x7f, 0x7f, 0x7f}, want: 1<<28 - 1},
+		// старший бит каждого байта не используется
+		{b: []byte{0x80, 0x80, 0x81, 0x80}, want: 128},
+	}
+
+	for _, tt := range tests {
+		if got := syncsafe(tt.b); got != tt.want {
+			t.Errorf("syncsafe(% x) = %d, want %d", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestUnsync(t *testing.T) {
+	tests := []struct {
+		b, want []byte
+	}{
+		{b: []byte{0xff, 0x00, 0xe0}, want: []byte{0xff, 0xe0}},
+		{b: []byte{0xff, 0x00, 0x00}, want: []byte{0xff, 0x00}},
+		{b: []byte{0xff, 0xff, 0x00}, want: []byte{0xff, 0xff}},
+		{b: []byte{0x00, 0xff, 0x01}, want: []byte{0x00, 0xff, 0x01}},
+	}
+
+	for _, tt := range tests {
+		if got := unsync(tt.b); !bytes.Equal(got, tt.want) {
+			t.Errorf("unsync(% x) = % x, want % x", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestReadID3v2(t *testing.T) {
+	long := string(bytes.Repeat([]byte("a"), 300))
+
+	// кадр SYLT: кодировка, язык, формат времени, тип содержимого, описание,
+	// затем строки с 4-байтным временем
+	sylt := concat([]byte{0}, []byte("eng"), []byte{2, 1}, []byte("desc\x00"),
+		[]byte("First line\x00"), []byte{0, 0, 0x03, 0xe8},
+		[]byte("  \x00"), []byte{0, 0, 0x07, 0xd0},
+		[]byte("Second line\x00"), []byte{0, 0, 0x0b, 0xb8})
+
+	tests := []struct {
+		name string
+		tag  []byte
+		want Tags
+	}{
+		{
+			name: "v2.3 text frames and TYER with TDAT",
+			tag: id3Tag(3, 0, concat(
+				id3Frame(3, "TIT2", 0, []byte("\x00Uprising")),
+				id3Frame(3, "TPE1", 0, []byte("\x03Muse")),
+				id3Frame(3, "TYER", 0, []byte("\x002009")),
+				id3Frame(3, "TDAT", 0, []byte("\x001609")),
+			)),
+			want: Tags{Title: "Uprising", Artist: "Muse", Date: "16-09-2009"},
+		},
+		{
+			name: "v2.4 syncsafe frame size and TDRC",
+			tag: id3Tag(4, 0, concat(
+				id3Frame(4, "TIT2", 0, []byte("\x03"+long)),
+				id3Frame(4, "TDRC", 0, []byte("\x032006-07-16T10:00")),
+			)),
+			want: Tags{Title: long, Date: "2006-07-16"},
+		},
+		{
+			name: "v2.2 frame mapping",
+			tag: id3Tag(2, 0, concat(
+				id3Frame(2, "TT2", 0, []byte("\x00Starlight")),
+				id3Frame(2, "TP1", 0, []byte("\x00Muse")),
+				id3Frame(2, "TYE", 0, []byte("\x002006")),
+				id3Frame(2, "ULT", 0, []byte("\x00eng\x00Far away\r\nThis ship")),
+			)),
+			want: Tags{Title: "Starlight", Artist: "Muse", Date: "2006", Lyrics: "Far away\nThis ship"},
+		},
+		{
+			name: "v2.2 compressed tag is skipped",
+			tag:  id3Tag(2, 0x40, id3Frame(2, "TT2", 0, []byte("\x00Starlight"))),
+			want: Tags{},
+		},
+		{
+			name: "UTF-16 with little-endian BOM",
+			tag:  id3Tag(3, 0, id3Frame(3, "TIT2", 0, concat([]byte{1}, utf16Text("Группа крови", false), []byte{0, 0}))),
+			want: Tags{Title: "Группа крови"},
+		},
+		{
+			name: "UTF-16 with big-endian BOM",
+			tag:  id3Tag(3, 0, id3Frame(3, "TPE1", 0, concat([]byte{1}, utf16Text("Кино", true)))),
+			want: Tags{Artist: "Кино"},
+		},
+		{
+			name: "UTF-16BE without BOM",
+			tag:  id3Tag(4, 0, id3Frame(4, "TPE1", 0, []byte{2, 0x04, 0x1a, 0x04, 0x38, 0x04, 0x3d, 0x04, 0x3e})),
+			want: Tags{Artist: "Кино"},
+		},
+		{
+			name: "USLT in UTF-16 with description",
+			tag: id3Tag(3, 0, id3Frame(3, "USLT", 0, concat([]byte{1}, []byte("rus"),
+				utf16Text("описание", false), []byte{0, 0}, utf16Text("Теплое место,\nно улицы ждут", false)))),
+			want: Tags{Lyrics: "Теплое место,\nно улицы ждут"},
+		},
+		{
+			name: "v2.3 tag unsynchronisation",
+			// 0xFF 0xE0 ("ÿà" в ISO-8859-1) записан как 0xFF 0x00 0xE0, размер кадра - после восстановления
+			tag:  id3Tag(3, 0x80, concat([]byte("TIT2"), []byte{0, 0, 0, 3, 0, 0}, []byte{0, 0xff, 0x00, 0xe0})),
+			want: Tags{Title: "ÿà"},
+		},
+		{
+			name: "v2.4 frame unsynchronisation",
+			tag:  id3Tag(4, 0, id3Frame(4, "TIT2", 0x02, []byte{0, 0xff, 0x00, 0xe0})),
+			want: Tags{Title: "ÿà"},
+		},
+		{
+			name: "v2.3 extended header is skipped",
+			tag: id3Tag(3, 0x40, concat([]byte{0, 0, 0, 6}, make([]byte, 6),
+				id3Frame(3, "TIT2", 0, []byte("\x00Uprising")))),
+			want: Tags{Title: "Uprising"},
+		},
+		{
+			name: "compressed frame is skipped",
+			tag: id3Tag(3, 0, concat(
+				id3Frame(3, "TIT2", 0x80, []byte("\x00compressed")),
+				id3Frame(3, "TPE1", 0, []byte("\x00Muse")),
+			)),
+			want: Tags{Artist: "Muse"},
+		},
+		{
+			name: "padding stops frames",
+			tag:  id3Tag(3, 0, concat(id3Frame(3, "TIT2", 0, []byte("\x00Uprising")), make([]byte, 32))),
+			want: Tags{Title: "Uprising"},
+		},
+		{
+			name: "SYLT without timings",
+			tag:  id3Tag(3, 0, id3Frame(3, "SYLT", 0, sylt)),
+			want: Tags{Lyrics: "First line\nSecond line", Synced: true},
+		},
+		{
+			name: "v2.2 SLT mapping",
+			tag:  id3Tag(2, 0, id3Frame(2, "SLT", 0, sylt)),
+			want: Tags{Lyrics: "First line\nSecond line", Synced: true},
+		},
+		{
+			name: "USLT preferred over SYLT",
+			tag: id3Tag(3, 0, concat(
+				id3Frame(3, "SYLT", 0, sylt),
+				id3Frame(3, "USLT", 0, []byte("\x00eng\x00Plain")),
+			)),
+			want: Tags{Lyrics: "Plain"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found, err := readID3v2(bytes.NewReader(tt.tag))
+			if err != nil {
+				t.Fatalf("readID3v2() error = %v", err)
+			}
+			if !found {
+				t.Fatal("tag not found")
+			}
+			if got != tt.want {
+				t.Errorf("readID3v2() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadID3v2Errors(t *testing.T) {
+	// тега нет - позиция возвращается в начало
+	r := bytes.NewReader([]byte("fLaC and more"))
+	if _, found, err := readID3v2(r); err != nil || found {
+		t.Errorf("readID3v2() found = %v, error = %v, want no tag", found, err)
+	}
+	if pos, _ := r.Seek(0, io.SeekCurrent); pos != 0 {
+		t.Errorf("reader position = %d, want 0", pos)
+	}
+
+	// тег короче заявленного размера
+	tag := id3Tag(3, 0, id3Frame(3, "TIT2", 0, []byte("\x00Uprising")))
+	if _, _, err := readID3v2(bytes.NewReader(tag[:len(tag)-4])); err == nil {
+		t.Error("readID3v2() of truncated tag: expected error")
+	}
+
+	// размер кадра больше тега
+	tag = id3Tag(3, 0, concat([]byte("TIT2"), []byte{0, 0, 1, 0, 0, 0}, []byte("\x00Uprising")))
+	if got, _, err := readID3v2(bytes.NewReader(tag)); err != nil || got != (Tags{}) {
+		t.Errorf("readID3v2() = %+v, %v, want empty tags", got, err)
+	}
+}
+
+func TestReadMP3(t *testing.T) {
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "Title from v1")
+	copy(v1[33:], "Artist from v1")
+	copy(v1[93:], "2009")
+
+	audio := make([]byte, 256)
+
+	tests := []struct {
+		name string
+		file []byte
+		want Tags
+	}{
+		{
+			name: "v2 completed from v1",
+			file: concat(id3Tag(3, 0, id3Frame(3, "TIT2", 0, []byte("\x00Uprising"))), audio, v1),
+			want: Tags{Title: "Uprising", Artist: "Artist from v1", Date: "2009"},
+		},
+		{
+			name: "v1 only",
+			file: concat(audio, v1),
+			want: Tags{Title: "Title from v1", Artist: "Artist from v1", Date: "2009"},
+		},
+		{
+			name: "v2.4 footer",
+			file: concat(id3Tag(4, 0x10, id3Frame(4, "TPE1", 0, []byte("\x03Muse"))), []byte("3DI"), make([]byte, 7), audio),
+			want: Tags{Artist: "Muse"},
+		},
+		{
+			name: "no tags",
+			file: audio,
+			want: Tags{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readMP3(bytes.NewReader(tt.file))
+			if err != nil {
+				t.Fatalf("readMP3() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readMP3() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
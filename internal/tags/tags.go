@@ -0,0 +1,70 @@
+package tags
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupported возвращается для файлов, формат которых не поддерживается
+var ErrUnsupported = errors.New("unsupported file format")
+
+// maxTagSize ограничение размера блока метаданных
+const maxTagSize = 64 << 20
+
+// Tags метаданные аудиофайла, нужные библиотеке
+type Tags struct {
+	Title  string // название песни
+	Artist string // исполнитель
+	Date   string // дата или год релиза в том виде, в каком она записана в файле
+	Lyrics string // текст песни
+	// Synced текст получен из синхронизированной записи (SYLT), тайминги отброшены
+	Synced bool
+}
+
+// merge дополняет пустые поля значениями из other
+func (t *Tags) merge(other Tags) {
+	if t.Title == "" {
+		t.Title = other.Title
+	}
+	if t.Artist == "" {
+		t.Artist = other.Artist
+	}
+	if t.Date == "" {
+		t.Date = other.Date
+	}
+	if t.Lyrics == "" {
+		t.Lyrics = other.Lyrics
+		t.Synced = other.Synced
+	}
+}
+
+// Supported проверяет по расширению, умеет ли пакет читать метаданные файла
+func Supported(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3", ".flac", ".ogg", ".oga", ".opus":
+		return true
+	}
+	return false
+}
+
+// Read читает метаданные файла: ID3v2 и ID3v1 для MP3, Vorbis comments для FLAC,
+// Ogg Vorbis и Opus. Содержимое аудиопотока не читается
+func Read(path string) (Tags, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Tags{}, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp3":
+		return readMP3(f)
+	case ".flac":
+		return readFLAC(f)
+	case ".ogg", ".oga", ".opus":
+		return readOgg(f)
+	}
+	return Tags{}, ErrUnsupported
+}
@@ -0,0 +1,195 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// readFLAC читает блок VORBIS_COMMENT из метаданных FLAC
+func readFLAC(f io.ReadSeeker) (Tags, error) {
+	// перед потоком FLAC иногда записывают ID3v2, его данные используются как дополнение
+	id3, _, err := readID3v2(f)
+	if err != nil {
+		return id3, err
+	}
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil || string(magic) != "fLaC" {
+		return id3, errors.New("not a FLAC file")
+	}
+
+	var t Tags
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(f, header); err != nil {
+			return t, fmt.Errorf("failed to read FLAC metadata: %w", err)
+		}
+		last := header[0]&0x80 != 0
+		blockType := header[0] & 0x7f
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if blockType == 4 {
+			data := make([]byte, size)
+			if _, err := io.ReadFull(f, data); err != nil {
+				return t, fmt.Errorf("failed to read FLAC comments: %w", err)
+			}
+			if t, err = parseVorbisComment(data); err != nil {
+				return t, err
+			}
+		} else if _, err := f.Seek(size, io.SeekCurrent); err != nil {
+			return t, err
+		}
+
+		if last {
+			break
+		}
+	}
+
+	t.merge(id3)
+	return t, nil
+}
+
+// readOgg читает заголовок комментариев первого потока Ogg Vorbis или Opus
+func readOgg(r io.Reader) (Tags, error) {
+	r = io.LimitReader(r, maxTagSize)
+
+	var (
+		packets [][]byte
+		packet  []byte
+		serial  uint32
+		header  = make([]byte, 27)
+	)
+
+	for len(packets) < 2 {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return Tags{}, fmt.Errorf("failed to read Ogg page: %w", err)
+		}
+		if string(header[:4]) != "OggS" {
+			return Tags{}, errors.New("not an Ogg file")
+		}
+
+		pageSerial := binary.LittleEndian.Uint32(header[14:18])
+		if len(packets) == 0 && packet == nil {
+			serial = pageSerial
+		}
+
+		lacing := make([]byte, header[26])
+		if _, err := io.ReadFull(r, lacing); err != nil {
+			return Tags{}, err
+		}
+		var total int
+		for _, l := range lacing {
+			total += int(l)
+		}
+		data := make([]byte, total)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return Tags{}, err
+		}
+
+		// страницы других логических потоков пропускаем
+		if pageSerial != serial {
+			continue
+		}
+
+		for _, l := range lacing {
+			packet = append(packet, data[:l]...)
+			data = data[l:]
+			if l < 255 {
+				packets = append(packets, packet)
+				packet = nil
+				if len(packets) == 2 {
+					break
+				}
+			}
+		}
+	}
+
+	comment := packets[1]
+	switch {
+	case bytes.HasPrefix(comment, []byte("\x03vorbis")):
+		comment = comment[7:]
+	case bytes.HasPrefix(comment, []byte("OpusTags")):
+		comment = comment[8:]
+	default:
+		return Tags{}, ErrUnsupported
+	}
+
+	return parseVorbisComment(comment)
+}
+
+// parseVorbisComment разбирает блок комментариев Vorbis: строка производителя
+// и список пар KEY=value, длины в little-endian
+func parseVorbisComment(data []byte) (Tags, error) {
+	var t Tags
+	errInvalid := errors.New("invalid Vorbis comment block")
+
+	next := func() ([]byte, bool) {
+		if len(data) < 4 {
+			return nil, false
+		}
+		n := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		if uint64(n) > uint64(len(data)) {
+			return nil, false
+		}
+		v := data[:n]
+		data = data[n:]
+		return v, true
+	}
+
+	if _, ok := next(); !ok {
+		return t, errInvalid
+	}
+	if len(data) < 4 {
+		return t, errInvalid
+	}
+	count := binary.LittleEndian.Uint32(data)
+	data = data[4:]
+
+	var albumArtist string
+	for i := uint32(0); i < count; i++ {
+		entry, ok := next()
+		if !ok {
+			return t, errInvalid
+		}
+		key, value, found := strings.Cut(string(entry), "=")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			if t.Title == "" {
+				t.Title = value
+			}
+		case "ARTIST":
+			if t.Artist == "" {
+				t.Artist = value
+			}
+		case "ALBUMARTIST":
+			albumArtist = value
+		case "DATE", "YEAR":
+			if t.Date == "" {
+				if len(value) > 10 {
+					value = value[:10]
+				}
+				t.Date = value
+			}
+		case "LYRICS", "UNSYNCEDLYRICS", "UNSYNCED LYRICS":
+			if lyrics := normalizeLyrics(value); len(lyrics) > len(t.Lyrics) {
+				t.Lyrics = lyrics
+			}
+		}
+	}
+
+	if t.Artist == "" {
+		t.Artist = albumArtist
+	}
+
+	return t, nil
+}
@@ -0,0 +1,254 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// vorbisComment собирает блок комментариев Vorbis
+func vorbisComment(vendor string, entries ...string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, uint32(len(vendor)))
+	b = append(b, vendor...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(entries)))
+	for _, e := range entries {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(e)))
+		b = append(b, e...)
+	}
+	return b
+}
+
+// oggSegment часть пакета на странице Ogg. Если end равен false, пакет продолжается
+// на следующей странице, и длина части должна быть кратна 255
+type oggSegment struct {
+	data []byte
+	end  bool
+}
+
+// oggPage собирает страницу Ogg логического потока serial. Контрольная сумма не заполняется
+func oggPage(serial uint32, segments ...oggSegment) []byte {
+	var lacing, data []byte
+	for _, s := range segments {
+		n := len(s.data)
+		for ; n >= 255; n -= 255 {
+			lacing = append(lacing, 255)
+		}
+		if s.end {
+			lacing = append(lacing, byte(n))
+		}
+		data = append(data, s.data...)
+	}
+
+	header := make([]byte, 27)
+	copy(header, "OggS")
+	binary.LittleEndian.PutUint32(header[14:18], serial)
+	header[26] = byte(len(lacing))
+
+	return concat(header, lacing, data)
+}
+
+// flacBlock собирает блок метаданных FLAC
+func flacBlock(blockType byte, last bool, data []byte) []byte {
+	if last {
+		blockType |= 0x80
+	}
+	n := len(data)
+	return append([]byte{blockType, byte(n >> 16), byte(n >> 8), byte(n)}, data...)
+}
+
+func TestParseVorbisComment(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Tags
+		wantErr bool
+	}{
+		{
+			name: "fields in any case",
+			data: vorbisComment("libFLAC 1.4.3", "title=Uprising", "ARTIST=Muse", "Date=2009-09-07T00:00:00", "COMMENT=ignored"),
+			want: Tags{Title: "Uprising", Artist: "Muse", Date: "2009-09-07"},
+		},
+		{
+			name: "first value of repeated field",
+			data: vorbisComment("", "ARTIST=Muse", "ARTIST=Matthew Bellamy", "YEAR=2009"),
+			want: Tags{Artist: "Muse", Date: "2009"},
+		},
+		{
+			name: "album artist fallback",
+			data: vorbisComment("", "TITLE=Uprising", "ALBUMARTIST=Muse"),
+			want: Tags{Title: "Uprising", Artist: "Muse"},
+		},
+		{
+			name: "longest lyrics",
+			data: vorbisComment("", "LYRICS=short", "UNSYNCEDLYRICS=Paranoia is in bloom\r\nThe PR transmissions will resume\r\n"),
+			want: Tags{Lyrics: "Paranoia is in bloom\nThe PR transmissions will resume"},
+		},
+		{
+			name: "entry without separator",
+			data: vorbisComment("", "garbage", "TITLE=Uprising"),
+			want: Tags{Title: "Uprising"},
+		},
+		{
+			name:    "entry longer than block",
+			data:    vorbisComment("", "TITLE=Uprising")[:20],
+			wantErr: true,
+		},
+		{
+			name:    "missing entry count",
+			data:    binary.LittleEndian.AppendUint32(nil, 0),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVorbisComment(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVorbisComment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseVorbisComment() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadOgg(t *testing.T) {
+	vorbisID := append([]byte("\x01vorbis"), make([]byte, 23)...)
+	opusHead := append([]byte("OpusHead"), make([]byte, 11)...)
+
+	lyrics := strings.Repeat("Теплое место, но улицы ждут\n", 20)
+	comment := append([]byte("\x03vorbis"), vorbisComment("Xiph.Org libVorbis", "TITLE=Группа крови", "ARTIST=Кино", "LYRICS="+lyrics)...)
+	// пакет делится на части, кратные 255 байтам, для продолжения на следующей странице
+	split := len(comment) / 255 * 255
+
+	opusTags := append([]byte("OpusTags"), vorbisComment("libopus", "TITLE=Uprising", "ARTIST=Muse")...)
+	// пакет длиной ровно 255 байт завершается сегментом нулевой длины
+	exact := append([]byte("OpusTags"), vorbisComment("libopus", "TITLE=Uprising", "ARTIST=Muse")...)
+	exact = append(exact, bytes.Repeat([]byte{0}, 255-len(exact))...)
+
+	full := Tags{Title: "Группа крови", Artist: "Кино", Lyrics: strings.TrimSpace(lyrics)}
+
+	tests := []struct {
+		name    string
+		file    []byte
+		want    Tags
+		wantErr bool
+	}{
+		{
+			name: "packets on separate pages",
+			file: concat(oggPage(1, oggSegment{vorbisID, true}), oggPage(1, oggSegment{comment, true})),
+			want: full,
+		},
+		{
+			name: "comment continued on next page",
+			file: concat(
+				oggPage(1, oggSegment{vorbisID, true}, oggSegment{comment[:split], false}),
+				oggPage(1, oggSegment{comment[split:], true}),
+			),
+			want: full,
+		},
+		{
+			name: "pages of other stream skipped",
+			file: concat(
+				oggPage(7, oggSegment{vorbisID, true}),
+				oggPage(7, oggSegment{comment[:split], false}),
+				oggPage(9, oggSegment{opusTags, true}),
+				oggPage(7, oggSegment{comment[split:], true}),
+			),
+			want: full,
+		},
+		{
+			name: "opus",
+			file: oggPage(3, oggSegment{opusHead, true}, oggSegment{opusTags, true}),
+			want: Tags{Title: "Uprising", Artist: "Muse"},
+		},
+		{
+			name: "packet of exactly 255 bytes",
+			file: oggPage(3, oggSegment{opusHead, true}, oggSegment{exact, true}),
+			want: Tags{Title: "Uprising", Artist: "Muse"},
+		},
+		{
+			name:    "unknown codec",
+			file:    oggPage(1, oggSegment{[]byte("\x7fFLAC"), true}, oggSegment{[]byte("something"), true}),
+			wantErr: true,
+		},
+		{
+			name:    "not ogg",
+			file:    append([]byte("RIFF"), make([]byte, 64)...),
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			file:    oggPage(1, oggSegment{vorbisID, true}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readOgg(bytes.NewReader(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readOgg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("readOgg() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFLAC(t *testing.T) {
+	streamInfo := flacBlock(0, false, make([]byte, 34))
+	padding := flacBlock(1, false, make([]byte, 1024))
+	comment := vorbisComment("reference libFLAC 1.4.3", "TITLE=Uprising", "ARTIST=Muse")
+
+	tests := []struct {
+		name    string
+		file    []byte
+		want    Tags
+		wantErr bool
+	}{
+		{
+			name: "comment after other blocks",
+			file: concat([]byte("fLaC"), streamInfo, padding, flacBlock(4, false, comment), flacBlock(6, true, make([]byte, 16))),
+			want: Tags{Title: "Uprising", Artist: "Muse"},
+		},
+		{
+			name: "no comment block",
+			file: concat([]byte("fLaC"), streamInfo, flacBlock(1, true, make([]byte, 8))),
+			want: Tags{},
+		},
+		{
+			name: "completed from ID3v2",
+			file: concat(id3Tag(3, 0, concat(
+				id3Frame(3, "TIT2", 0, []byte("\x00ID3 title")),
+				id3Frame(3, "TYER", 0, []byte("\x002009")),
+			)), []byte("fLaC"), streamInfo, flacBlock(4, true, comment)),
+			want: Tags{Title: "Uprising", Artist: "Muse", Date: "2009"},
+		},
+		{
+			name:    "last block flag missing",
+			file:    concat([]byte("fLaC"), streamInfo, flacBlock(4, false, comment)),
+			wantErr: true,
+		},
+		{
+			name:    "not flac",
+			file:    []byte("OggS and more"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readFLAC(bytes.NewReader(tt.file))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readFLAC() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("readFLAC() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package translit
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// cyrillic транслитерация кириллицы в латиницу (упрощенный ГОСТ 7.79 Б).
+// Мягкий и твердый знаки отбрасываются, "ё" приравнивается к "е"
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "c",
+	'ч': "ch", 'ш': "sh", 'щ': "sh", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "yi", 'є': "e", 'ґ': "g",
+}
+
+// iso9 буквы с диакритикой из ISO 9, которые нельзя просто очистить от диакритики
+var iso9 = map[rune]string{
+	'č': "ch", 'š': "sh", 'ŝ': "sh", 'ž': "zh", 'â': "ya", 'û': "yu", 'ê': "e",
+	'ï': "yi", 'è': "e", 'ë': "e", 'ʹ': "", 'ʺ': "", '\'': "", '’': "",
+}
+
+// digraphs приводит варианты неформальной и научной транслитерации к одному виду.
+// Порядок важен: более длинные сочетания заменяются первыми
+var digraphs = strings.NewReplacer(
+	"shch", "sh",
+	"sch", "sh",
+	"shh", "sh",
+	"tsch", "ch",
+	"kh", "h",
+	"ts", "c",
+	"tz", "c",
+	"ja", "ya",
+	"ju", "yu",
+	"je", "e",
+	"ye", "e",
+	"jo", "e",
+	"yo", "e",
+	"j", "y",
+)
+
+// Key возвращает нормализованный поисковый ключ строки.
+// Кириллица транслитерируется, варианты латинской записи (ГОСТ, ISO 9,
+// неформальные) сводятся к одному виду, поэтому "Кино" и "Kino",
+// "Цой" и "Tsoi", "Ёлка" и "Yolka" дают одинаковые ключи
+func Key(s string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(norm.NFC.String(s)) {
+		if v, ok := cyrillic[r]; ok {
+			b.WriteString(v)
+			continue
+		}
+		if v, ok := iso9[r]; ok {
+			b.WriteString(v)
+			continue
+		}
+
+		// отбрасываем оставшуюся диакритику
+		for _, d := range norm.NFD.String(string(r)) {
+			switch {
+			case unicode.Is(unicode.Mn, d):
+			case unicode.IsLetter(d) || unicode.IsDigit(d):
+				b.WriteRune(d)
+			default:
+				b.WriteRune(' ')
+			}
+		}
+	}
+
+	return fold(digraphs.Replace(b.String()))
+}
+
+// fold схлопывает пробелы и повторяющиеся буквы и приводит "й" после гласной к "i"
+func fold(s string) string {
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+	out := make([]rune, 0, len(runes))
+
+	for i, r := range runes {
+		if r == 'y' && len(out) > 0 && isVowel(out[len(out)-1]) && (i+1 == len(runes) || !isVowel(runes[i+1])) {
+			r = 'i'
+		}
+		if len(out) > 0 && out[len(out)-1] == r && r != ' ' {
+			continue
+		}
+		out = append(out, r)
+	}
+
+	return string(out)
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiou", r)
+}